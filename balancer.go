@@ -2,6 +2,7 @@ package balancer
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
 
 	"github.com/visheratin/balancer/curve"
@@ -42,8 +43,12 @@ func (b *Balancer) Space() *Space {
 }
 
 // AddNode adds node to the Space of balancer, and initiates rebalancing of cells
-// between cell groups.
+// between cell groups. Addition and optimization run on the clone of the space first,
+// so the node is not added if cells can not be rebalanced.
 func (b *Balancer) AddNode(n Node, optimize bool) error {
+	if b.space.Migration() != nil {
+		return fmt.Errorf("unable to add node: %w", ErrMigrationInProgress)
+	}
 	if b.space.Len() == 0 || b.nType == nil {
 		b.nType = reflect.TypeOf(n)
 		//return b.space.AddNode(n)
@@ -51,15 +56,25 @@ func (b *Balancer) AddNode(n Node, optimize bool) error {
 	//else if reflect.TypeOf(n) != b.nType {
 	//	return errors.New("incorrect node type")
 	//}
+	var cgs []*CellGroup
+	if optimize {
+		clone := b.space.Clone()
+		if err := clone.AddNode(n); err != nil {
+			return err
+		}
+		var err error
+		if cgs, err = b.of(clone); err != nil {
+			return err
+		}
+		if err := clone.ValidateGroups(cgs); err != nil {
+			return err
+		}
+	}
 	if err := b.space.AddNode(n); err != nil {
 		return err
 	}
 	if optimize {
-		cgs, err := b.of(b.space)
-		if err != nil {
-			return err
-		}
-		b.space.SetGroups(cgs)
+		return b.Apply(cgs)
 	}
 	return nil
 }
@@ -76,16 +91,28 @@ func (b *Balancer) SFC() curve.Curve {
 	return b.Space().sfc
 }
 
+// RemoveNode removes node from the Space of balancer and rebalances its cells between the
+// rest of the nodes. Removal and optimization run on the clone of the space first, so the node
+// is kept if its cells can not be placed on the rest of the nodes, e.g. because of pins.
 func (b *Balancer) RemoveNode(id string) error {
-	if err := b.space.RemoveNode(id); err != nil {
+	if b.space.Migration() != nil {
+		return fmt.Errorf("unable to remove node: %w", ErrMigrationInProgress)
+	}
+	clone := b.space.Clone()
+	if err := clone.RemoveNode(id); err != nil {
 		return err
 	}
-	cgs, err := b.of(b.space)
+	cgs, err := b.of(clone)
 	if err != nil {
 		return err
 	}
-	b.space.SetGroups(cgs)
-	return nil
+	if err := clone.ValidateGroups(cgs); err != nil {
+		return err
+	}
+	if err := b.space.RemoveNode(id); err != nil {
+		return err
+	}
+	return b.Apply(cgs)
 }
 
 // AddData loads data into the Space of the balancer.
@@ -93,21 +120,133 @@ func (b *Balancer) AddData(d DataItem) (Node, error) {
 	return b.space.AddData(d)
 }
 
-// LocateData returns the node for specified data item.
-func (b *Balancer) LocateData(d DataItem) (Node, error) {
+// AddDataRoute loads data into the Space of the balancer and returns its route, which
+// contains all nodes receiving writes of the item during the migration.
+func (b *Balancer) AddDataRoute(d DataItem) (Route, error) {
+	return b.space.AddDataRoute(d)
+}
+
+// LocateData returns the route of specified data item, which contains the node and the epoch
+// of the routing decision.
+func (b *Balancer) LocateData(d DataItem) (Route, error) {
 	return b.space.LocateData(d)
 }
 
+// Epoch returns current epoch of the balancer's space.
+func (b *Balancer) Epoch() uint64 {
+	return b.space.Epoch()
+}
+
+// Watch returns the channel which receives the epoch every time nodes or cell groups
+// of the balancer change, and the function which stops watching.
+func (b *Balancer) Watch() (<-chan uint64, func()) {
+	return b.space.Watch()
+}
+
+// CheckRoute checks whether the routing decision made for the cell at the given epoch is still valid.
+func (b *Balancer) CheckRoute(cID, epoch uint64) (RouteStatus, error) {
+	return b.space.CheckRoute(cID, epoch)
+}
+
+// AddDataBatch loads data items into the Space of the balancer.
+func (b *Balancer) AddDataBatch(ds []DataItem) ([]Node, []error) {
+	return b.space.AddDataBatch(ds)
+}
+
+// AddDataBatchRoutes loads data items into the Space of the balancer and returns the route
+// of every item.
+func (b *Balancer) AddDataBatchRoutes(ds []DataItem) ([]Route, []error) {
+	return b.space.AddDataBatchRoutes(ds)
+}
+
+// LocateDataBatch returns the node for every data item in the batch.
+func (b *Balancer) LocateDataBatch(ds []DataItem) ([]Node, []error) {
+	return b.space.LocateDataBatch(ds)
+}
+
+// LocateNearest returns nodes holding k cells closest to the data item, ordered by distance.
+func (b *Balancer) LocateNearest(d DataItem, k int) ([]Node, error) {
+	return b.space.LocateNearest(d, k)
+}
+
+// ExportRoutes returns current routing state of the balancer encoded into binary form,
+// which can be loaded by clients with NewRouter.
+func (b *Balancer) ExportRoutes() ([]byte, error) {
+	rs, err := b.space.RoutingState()
+	if err != nil {
+		return nil, err
+	}
+	return rs.MarshalBinary()
+}
+
+// LocateRange returns nodes which hold cells from the range [min, max).
+func (b *Balancer) LocateRange(min, max uint64) ([]Node, error) {
+	return b.space.LocateRange(min, max)
+}
+
+// SetTransformer replaces the transform function of the balancer with the transformer.
+func (b *Balancer) SetTransformer(t Transformer) error {
+	return b.space.SetTransformer(t)
+}
+
+// Pin restricts cells from the range [min, max) to the nodes.
+func (b *Balancer) Pin(min, max uint64, nodes ...string) error {
+	return b.space.Pin(min, max, nodes...)
+}
+
+// PinRegion restricts cells of the region of the value space between lo and hi to the nodes.
+func (b *Balancer) PinRegion(lo, hi []interface{}, nodes ...string) error {
+	return b.space.PinRegion(lo, hi, nodes...)
+}
+
+// Unpin removes restrictions from cells of the range [min, max).
+func (b *Balancer) Unpin(min, max uint64) error {
+	return b.space.Unpin(min, max)
+}
+
+// Pins returns restrictions of cell placement.
+func (b *Balancer) Pins() []Pin {
+	return b.space.Pins()
+}
+
+// Optimize runs the optimizer on the clone of the space and returns new cell groups.
+// The space is not changed until the groups are passed to Apply.
 func (b *Balancer) Optimize() ([]*CellGroup, error) {
-	ns, err := b.of(b.space)
+	ns, err := b.of(b.space.Clone())
 	if err != nil {
 		return nil, err
 	}
 	return ns, nil
 }
 
-func (b *Balancer) Apply(ns []*CellGroup) {
-	b.space.cgs = ns
+// DryRun returns what would change if cell groups returned by Optimize were applied.
+func (b *Balancer) DryRun(ns []*CellGroup) Impact {
+	return b.space.DryRun(ns)
+}
+
+// Apply replaces cell groups of the space with the ones returned by Optimize.
+// It returns *PartitionError if ranges of cell groups do not form a valid partition of the space.
+func (b *Balancer) Apply(ns []*CellGroup) error {
+	if b.space.Migration() != nil {
+		return fmt.Errorf("unable to apply groups: %w", ErrMigrationInProgress)
+	}
+	if err := b.space.ValidateGroups(ns); err != nil {
+		return err
+	}
+	b.space.SetGroups(ns)
+	return nil
+}
+
+// StageApply starts staged transition to cell groups returned by Optimize, every step
+// of the migration moves at most maxBytes bytes. Apply is not allowed until the migration
+// is completed or aborted.
+func (b *Balancer) StageApply(ns []*CellGroup, maxBytes uint64) (*Migration, error) {
+	return b.space.StartMigration(ns, maxBytes)
+}
+
+// Migration returns the migration in progress or nil.
+func (b *Balancer) Migration() *Migration {
+	return b.space.Migration()
 }
 
 func Log2(n uint64) (p uint64, err error) {
@@ -0,0 +1,129 @@
+package balancer
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// minBatchChunk is the minimal number of items processed by one goroutine
+// when cell IDs are calculated for the batch.
+const minBatchChunk = 256
+
+// AddDataBatch adds data items to the space. Cell IDs are calculated in parallel
+// before acquiring the lock of the space, and the load is aggregated per cell,
+// so every cell and cell group is updated once per batch.
+// It returns node and error for every item in the batch.
+func (s *Space) AddDataBatch(ds []DataItem) ([]Node, []error) {
+	rs, errs := s.AddDataBatchRoutes(ds)
+	nodes := make([]Node, len(ds))
+	for iter := range rs {
+		nodes[iter] = rs[iter].Node
+	}
+	return nodes, errs
+}
+
+// AddDataBatchRoutes adds data items to the space like AddDataBatch and returns route and
+// error for every item in the batch. Write of the route contains both nodes while the cell
+// of the item is being moved by the migration.
+func (s *Space) AddDataBatchRoutes(ds []DataItem) ([]Route, []error) {
+	ids, errs := s.cellIDs(ds)
+	loads := map[uint64]uint64{}
+	for iter := range ds {
+		if errs[iter] == nil {
+			loads[ids[iter]] += ds[iter].Size()
+		}
+	}
+	routes := make([]Route, len(ds))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.cgs) == 0 {
+		return routes, fillErrors(errs, errors.New("no nodes in the cluster"))
+	}
+	for cID, l := range loads {
+		if _, ok := s.cells[cID]; !ok {
+			cg, ok := s.findCellGroup(cID)
+			if !ok {
+				continue
+			}
+			s.cells[cID] = NewCell(cID, nil, 0)
+			cg.AddCell(s.cells[cID], false)
+		}
+		s.cells[cID].addLoad(l)
+		s.load += l
+	}
+	rt := s.routingTable()
+	for iter := range ds {
+		if errs[iter] != nil {
+			continue
+		}
+		c, ok := s.cells[ids[iter]]
+		if !ok {
+			errs[iter] = errors.Errorf("unable to bind cell to cell group (cID=%v  d=%s)", ids[iter], ds[iter].ID())
+			continue
+		}
+		routes[iter] = s.cellRoute(rt, c)
+	}
+	return routes, errs
+}
+
+// LocateDataBatch returns node for every data item in the batch.
+// Cell IDs are calculated in parallel and located using the routing table of the space.
+func (s *Space) LocateDataBatch(ds []DataItem) ([]Node, []error) {
+	rt := s.routingTable()
+	ids, errs := s.cellIDs(ds)
+	nodes := make([]Node, len(ds))
+	if len(rt.nodes) == 0 {
+		return nodes, fillErrors(errs, errors.New("no nodes in the cluster"))
+	}
+	for iter := range ds {
+		if errs[iter] != nil {
+			continue
+		}
+		n, ok := rt.lookup(ids[iter])
+		if !ok {
+			errs[iter] = errors.Errorf("unable to find cell group for cell (cID=%v  d=%s)", ids[iter], ds[iter].ID())
+			continue
+		}
+		nodes[iter] = n
+	}
+	return nodes, errs
+}
+
+// cellIDs calculates cell IDs of data items in parallel.
+func (s *Space) cellIDs(ds []DataItem) ([]uint64, []error) {
+	ids := make([]uint64, len(ds))
+	errs := make([]error, len(ds))
+	workers := runtime.GOMAXPROCS(0)
+	chunk := (len(ds) + workers - 1) / workers
+	if chunk < minBatchChunk {
+		chunk = minBatchChunk
+	}
+	wg := sync.WaitGroup{}
+	for start := 0; start < len(ds); start += chunk {
+		end := start + chunk
+		if end > len(ds) {
+			end = len(ds)
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for iter := start; iter < end; iter++ {
+				ids[iter], errs[iter] = s.cellID(ds[iter])
+			}
+		}(start, end)
+	}
+	wg.Wait()
+	return ids, errs
+}
+
+// fillErrors sets err for every item which does not have an error yet.
+func fillErrors(errs []error, err error) []error {
+	for iter := range errs {
+		if errs[iter] == nil {
+			errs[iter] = err
+		}
+	}
+	return errs
+}
@@ -0,0 +1,84 @@
+package balancer
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+func randomItems(n int, dims, bits uint64, seed int64) []DataItem {
+	rnd := rand.New(rand.NewSource(seed))
+	res := make([]DataItem, n)
+	for iter := range res {
+		coords := make([]uint64, dims)
+		for d := range coords {
+			coords[d] = uint64(rnd.Intn(1 << bits))
+		}
+		res[iter] = testItem{
+			id:     fmt.Sprintf("d%d", iter),
+			size:   uint64(rnd.Intn(100) + 1),
+			coords: coords,
+		}
+	}
+	return res
+}
+
+func TestSpace_AddDataBatch(t *testing.T) {
+	items := randomItems(2000, 2, 6, 1)
+	items = append(items, testItem{id: "bad", size: 1, coords: []uint64{1 << 6, 0}})
+	single := newTestSpace(t, curve.Hilbert, 2, 6, "n1", "n2", "n3")
+	batch := newTestSpace(t, curve.Hilbert, 2, 6, "n1", "n2", "n3")
+	nodes, errs := batch.AddDataBatch(items)
+	for iter, d := range items {
+		n, err := single.AddData(d)
+		if (err != nil) != (errs[iter] != nil) {
+			t.Fatalf("item %s: AddDataBatch() error = %v, AddData() error = %v", d.ID(), errs[iter], err)
+		}
+		if err != nil {
+			continue
+		}
+		if n.ID() != nodes[iter].ID() {
+			t.Errorf("item %s: AddDataBatch() node = %s, AddData() node = %s", d.ID(), nodes[iter].ID(), n.ID())
+		}
+	}
+	if single.TotalLoad() != batch.TotalLoad() {
+		t.Errorf("AddDataBatch() total load = %d, want %d", batch.TotalLoad(), single.TotalLoad())
+	}
+	scgs, bcgs := single.CellGroups(), batch.CellGroups()
+	for iter := range scgs {
+		if scgs[iter].TotalLoad() != bcgs[iter].TotalLoad() {
+			t.Errorf("group %s: AddDataBatch() load = %d, want %d", scgs[iter].ID(), bcgs[iter].TotalLoad(), scgs[iter].TotalLoad())
+		}
+	}
+	located, errs := batch.LocateDataBatch(items)
+	for iter := range items {
+		if errs[iter] != nil {
+			continue
+		}
+		if located[iter].ID() != nodes[iter].ID() {
+			t.Errorf("item %s: LocateDataBatch() node = %s, want %s", items[iter].ID(), located[iter].ID(), nodes[iter].ID())
+		}
+	}
+}
+
+func BenchmarkSpace_AddData(b *testing.B) {
+	items := randomItems(100000, 2, 16, 1)
+	b.Run("single", func(b *testing.B) {
+		for iter := 0; iter < b.N; iter++ {
+			s := newTestSpace(b, curve.Hilbert, 2, 16, "n1", "n2", "n3")
+			for _, d := range items {
+				if _, err := s.AddData(d); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("batch", func(b *testing.B) {
+		for iter := 0; iter < b.N; iter++ {
+			s := newTestSpace(b, curve.Hilbert, 2, 16, "n1", "n2", "n3")
+			s.AddDataBatch(items)
+		}
+	})
+}
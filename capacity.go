@@ -4,3 +4,10 @@ package balancer
 type Capacity interface {
 	Get() float64
 }
+
+// CapacityValue is a constant amount of data which the node can hold.
+type CapacityValue float64
+
+func (c CapacityValue) Get() float64 {
+	return float64(c)
+}
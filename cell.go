@@ -54,3 +54,10 @@ func (c *cell) add(d DataItem) error {
 	c.cg.addLoad(d.Size())
 	return nil
 }
+
+func (c *cell) addLoad(l uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load += l
+	c.cg.addLoad(l)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/visheratin/balancer"
+)
+
+// nodeSpec describes a node of the cluster. Lines of the nodes file are either
+// JSON objects {"id": "n1", "power": 1, "capacity": 1000} or CSV rows "id,power,capacity".
+type nodeSpec struct {
+	ID       string  `json:"id"`
+	Power    float64 `json:"power"`
+	Capacity float64 `json:"capacity"`
+}
+
+// event is a step of the scenario. Lines of the scenario file are either JSON objects
+// {"action": "add", "id": "n4", "power": 1, "capacity": 1000}, {"action": "remove", "id": "n1"}
+// or CSV rows "add,n4,1,1000" and "remove,n1".
+type event struct {
+	Action string `json:"action"`
+	nodeSpec
+}
+
+// dataRecord is a data item. Lines of the data file are either JSON objects
+// {"id": "d1", "size": 10, "values": [55.75, 37.62]} or CSV rows "d1,10,55.75,37.62".
+// Numeric CSV values are parsed as float64, other values are kept as strings.
+type dataRecord struct {
+	ItemID     string        `json:"id"`
+	ItemSize   uint64        `json:"size"`
+	ItemValues []interface{} `json:"values"`
+}
+
+func (d dataRecord) ID() string {
+	return d.ItemID
+}
+
+func (d dataRecord) Size() uint64 {
+	return d.ItemSize
+}
+
+func (d dataRecord) Values() []interface{} {
+	return d.ItemValues
+}
+
+func readNodes(path string) ([]nodeSpec, error) {
+	var res []nodeSpec
+	err := readRecords(path, func(line []byte) error {
+		n := nodeSpec{}
+		if err := json.Unmarshal(line, &n); err != nil {
+			return err
+		}
+		res = append(res, n)
+		return nil
+	}, func(row []string) error {
+		n, err := parseNodeSpec(row)
+		if err != nil {
+			return err
+		}
+		res = append(res, n)
+		return nil
+	})
+	return res, err
+}
+
+func readScenario(path string) ([]event, error) {
+	var res []event
+	err := readRecords(path, func(line []byte) error {
+		e := event{}
+		if err := json.Unmarshal(line, &e); err != nil {
+			return err
+		}
+		res = append(res, e)
+		return nil
+	}, func(row []string) error {
+		if len(row) < 2 {
+			return errors.New("event must contain action and node id")
+		}
+		e := event{Action: row[0], nodeSpec: nodeSpec{ID: row[1]}}
+		if len(row) > 2 {
+			n, err := parseNodeSpec(row[1:])
+			if err != nil {
+				return err
+			}
+			e.nodeSpec = n
+		}
+		res = append(res, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for iter, e := range res {
+		if e.Action != "add" && e.Action != "remove" {
+			return nil, errors.Errorf("event %d: unknown action %q", iter, e.Action)
+		}
+	}
+	return res, nil
+}
+
+func readData(path string) ([]balancer.DataItem, error) {
+	var res []balancer.DataItem
+	err := readRecords(path, func(line []byte) error {
+		d := dataRecord{}
+		if err := json.Unmarshal(line, &d); err != nil {
+			return err
+		}
+		res = append(res, d)
+		return nil
+	}, func(row []string) error {
+		if len(row) < 3 {
+			return errors.New("data item must contain id, size and values")
+		}
+		size, err := strconv.ParseUint(row[1], 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "invalid size")
+		}
+		d := dataRecord{
+			ItemID:     row[0],
+			ItemSize:   size,
+			ItemValues: make([]interface{}, len(row)-2),
+		}
+		for iter, v := range row[2:] {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				d.ItemValues[iter] = f
+			} else {
+				d.ItemValues[iter] = v
+			}
+		}
+		res = append(res, d)
+		return nil
+	})
+	return res, err
+}
+
+func parseNodeSpec(row []string) (nodeSpec, error) {
+	if len(row) != 3 {
+		return nodeSpec{}, errors.New("node must contain id, power and capacity")
+	}
+	p, err := strconv.ParseFloat(row[1], 64)
+	if err != nil {
+		return nodeSpec{}, errors.Wrap(err, "invalid power")
+	}
+	c, err := strconv.ParseFloat(row[2], 64)
+	if err != nil {
+		return nodeSpec{}, errors.Wrap(err, "invalid capacity")
+	}
+	return nodeSpec{ID: row[0], Power: p, Capacity: c}, nil
+}
+
+// readRecords reads JSONL file if its extension is .jsonl or .json, and CSV file otherwise.
+func readRecords(path string, jsonFn func([]byte) error, csvFn func([]string) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".jsonl" || ext == ".json" {
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		line := 0
+		for sc.Scan() {
+			line++
+			b := strings.TrimSpace(sc.Text())
+			if b == "" {
+				continue
+			}
+			if err := jsonFn([]byte(b)); err != nil {
+				return errors.Wrapf(err, "%s:%d", path, line)
+			}
+		}
+		return sc.Err()
+	}
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.Comment = '#'
+	r.TrimLeadingSpace = true
+	for line := 1; ; line++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "%s:%d", path, line)
+		}
+		if err := csvFn(row); err != nil {
+			return errors.Wrapf(err, "%s:%d", path, line)
+		}
+	}
+}
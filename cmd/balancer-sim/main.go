@@ -0,0 +1,227 @@
+// Command balancer-sim simulates partitioning of the data set between nodes of the cluster.
+//
+// It reads data points and node specifications, builds the balancer with the chosen curve,
+// transform and optimizer, ingests the data and prints per-node statistics. Then it applies
+// node add/remove events from the scenario file and prints statistics after every event.
+//
+// Usage:
+//
+//	balancer-sim -data points.csv -nodes nodes.csv [-scenario events.jsonl] [flags]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"github.com/visheratin/balancer"
+	"github.com/visheratin/balancer/curve"
+	"github.com/visheratin/balancer/optimizer"
+	"github.com/visheratin/balancer/transform"
+)
+
+type config struct {
+	data      string
+	nodes     string
+	scenario  string
+	curve     string
+	dims      uint64
+	size      uint64
+	transform string
+	optimizer string
+}
+
+func main() {
+	cfg := config{}
+	flag.StringVar(&cfg.data, "data", "", "CSV or JSONL file with data items (id, size, values...)")
+	flag.StringVar(&cfg.nodes, "nodes", "", "CSV or JSONL file with nodes (id, power, capacity)")
+	flag.StringVar(&cfg.scenario, "scenario", "", "CSV or JSONL file with node add/remove events")
+	flag.StringVar(&cfg.curve, "curve", "hilbert", "space-filling curve: hilbert or morton")
+	flag.Uint64Var(&cfg.dims, "dims", 2, "number of curve dimensions")
+	flag.Uint64Var(&cfg.size, "size", 256, "size of the curve dimension, power of 2")
+	flag.StringVar(&cfg.transform, "transform", "space", "transform: space, mercator, equal-area or kv")
+	flag.StringVar(&cfg.optimizer, "optimizer", "range", "optimizer: power, range or power-range")
+	flag.Parse()
+	if cfg.data == "" || cfg.nodes == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(cfg, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(cfg config, w io.Writer) error {
+	cType, err := curveType(cfg.curve)
+	if err != nil {
+		return err
+	}
+	tf, err := transformFunc(cfg.transform)
+	if err != nil {
+		return err
+	}
+	of, err := optimizerFunc(cfg.optimizer)
+	if err != nil {
+		return err
+	}
+	specs, err := readNodes(cfg.nodes)
+	if err != nil {
+		return errors.Wrap(err, "reading nodes")
+	}
+	items, err := readData(cfg.data)
+	if err != nil {
+		return errors.Wrap(err, "reading data")
+	}
+	var events []event
+	if cfg.scenario != "" {
+		if events, err = readScenario(cfg.scenario); err != nil {
+			return errors.Wrap(err, "reading scenario")
+		}
+	}
+	nodes := make([]balancer.Node, len(specs))
+	for iter, n := range specs {
+		nodes[iter] = balancer.NewNode(n.ID, n.Power, n.Capacity)
+	}
+	b, err := balancer.NewBalancer(cType, cfg.dims, cfg.size, tf, of, nodes)
+	if err != nil {
+		return err
+	}
+	_, errs := b.AddDataBatch(items)
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	fmt.Fprintf(w, "ingested %d of %d items\n", len(items)-failed, len(items))
+
+	prev := owners(b)
+	cgs, err := b.Optimize()
+	if err != nil {
+		return err
+	}
+	if err := b.Apply(cgs); err != nil {
+		return err
+	}
+	report(w, "initial optimization", b, prev)
+	for _, e := range events {
+		prev = owners(b)
+		var title string
+		switch e.Action {
+		case "add":
+			title = fmt.Sprintf("add node %s", e.ID)
+			err = b.AddNode(balancer.NewNode(e.ID, e.Power, e.Capacity), true)
+		case "remove":
+			title = fmt.Sprintf("remove node %s", e.ID)
+			err = b.RemoveNode(e.ID)
+		}
+		if err != nil {
+			return errors.Wrap(err, title)
+		}
+		report(w, title, b, prev)
+	}
+	return nil
+}
+
+// cellOwner is the node holding the cell and the load of the cell.
+type cellOwner struct {
+	node string
+	load uint64
+}
+
+// owners returns current owners of all populated cells.
+func owners(b *balancer.Balancer) map[uint64]cellOwner {
+	res := map[uint64]cellOwner{}
+	for _, c := range b.Space().Cells() {
+		ns, err := b.LocateRange(c.ID(), c.ID()+1)
+		if err != nil || len(ns) == 0 {
+			continue
+		}
+		res[c.ID()] = cellOwner{node: ns[0].ID(), load: c.Load()}
+	}
+	return res
+}
+
+func report(w io.Writer, title string, b *balancer.Balancer, prev map[uint64]cellOwner) {
+	var moved int
+	var movedBytes uint64
+	cur := owners(b)
+	for id, o := range cur {
+		if p, ok := prev[id]; ok && p.node != o.node {
+			moved++
+			movedBytes += o.load
+		}
+	}
+	s := b.Space()
+	totalLoad := float64(s.TotalLoad())
+	totalPower := s.TotalPower()
+	// CellGroups returns the slice of the space, it is copied to keep the order of nodes.
+	cgs := append([]*balancer.CellGroup(nil), s.CellGroups()...)
+	sort.Slice(cgs, func(i, j int) bool {
+		return cgs[i].Range().Min < cgs[j].Range().Min
+	})
+	fmt.Fprintf(w, "\n== %s (epoch %d) ==\n", title, b.Epoch())
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "node\tpower\tcapacity\tload\tload share\tpower share\tratio\trange\tlength\t")
+	maxRatio := 0.0
+	for _, cg := range cgs {
+		n := cg.Node()
+		r := cg.Range()
+		load := float64(cg.TotalLoad())
+		ls, ps, ratio := 0.0, n.Power().Get()/totalPower, 0.0
+		if totalLoad > 0 {
+			ls = load / totalLoad
+			ratio = ls / ps
+		}
+		maxRatio = math.Max(maxRatio, ratio)
+		fmt.Fprintf(tw, "%s\t%.2f\t%.0f\t%.0f\t%.3f\t%.3f\t%.3f\t[%d, %d)\t%d\t\n",
+			n.ID(), n.Power().Get(), n.Capacity().Get(), load, ls, ps, ratio, r.Min, r.Max, r.Len)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "imbalance ratio: %.3f\n", maxRatio)
+	fmt.Fprintf(w, "cells moved: %d, bytes moved: %d\n", moved, movedBytes)
+}
+
+func curveType(name string) (curve.CurveType, error) {
+	switch strings.ToLower(name) {
+	case "hilbert":
+		return curve.Hilbert, nil
+	case "morton":
+		return curve.Morton, nil
+	}
+	return 0, errors.Errorf("unknown curve %q", name)
+}
+
+func transformFunc(name string) (balancer.TransformFunc, error) {
+	switch strings.ToLower(name) {
+	case "space":
+		return transform.SpaceTransform, nil
+	case "mercator":
+		return transform.MercatorTransform, nil
+	case "equal-area":
+		return transform.EqualAreaTransform, nil
+	case "kv":
+		return transform.KVTransform, nil
+	}
+	return nil, errors.Errorf("unknown transform %q", name)
+}
+
+func optimizerFunc(name string) (balancer.OptimizerFunc, error) {
+	switch strings.ToLower(name) {
+	case "power":
+		return optimizer.PowerOptimizer, nil
+	case "range":
+		return optimizer.RangeOptimizer, nil
+	case "power-range":
+		return optimizer.PowerRangeOptimizer, nil
+	}
+	return nil, errors.Errorf("unknown optimizer %q", name)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	p := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "balancer-sim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfg := config{
+		data: writeFile(t, dir, "data.csv", "# id,size,lat,lon\n"+
+			"d1,10,55.75,37.62\n"+
+			"d2,20,40.71,-74.00\n"+
+			"d3,30,-33.86,151.20\n"+
+			"d4,40,35.68,139.69\n"),
+		nodes: writeFile(t, dir, "nodes.jsonl", `{"id": "n1", "power": 1, "capacity": 100}
+{"id": "n2", "power": 1, "capacity": 100}
+`),
+		scenario:  writeFile(t, dir, "scenario.csv", "add,n3,2,100\nremove,n1\n"),
+		curve:     "hilbert",
+		dims:      2,
+		size:      64,
+		transform: "space",
+		optimizer: "power",
+	}
+	out := &bytes.Buffer{}
+	if err := run(cfg, out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"ingested 4 of 4 items",
+		"== initial optimization",
+		"== add node n3",
+		"== remove node n1",
+		"imbalance ratio",
+		"cells moved",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("run() output does not contain %q:\n%s", want, out.String())
+		}
+	}
+
+	cfg.optimizer = "unknown"
+	if err := run(cfg, out); err == nil {
+		t.Error("run() expected error for unknown optimizer")
+	}
+}
@@ -28,3 +28,15 @@ func NewCurve(cType CurveType, dims, bits uint64) (Curve, error) {
 		return nil, errors.New("unknown curve type")
 	}
 }
+
+// TypeOf returns the type of the curve created by NewCurve.
+func TypeOf(c Curve) (CurveType, error) {
+	switch c.(type) {
+	case *hilbert.Curve:
+		return Hilbert, nil
+	case *morton.Curve:
+		return Morton, nil
+	default:
+		return 0, errors.New("unknown curve type")
+	}
+}
@@ -405,8 +405,9 @@ func TestNew(t *testing.T) {
 					0x33,
 					0x55,
 				},
-				maxSize: 15,
-				maxCode: 255,
+				lshiftsArray: []uint64{0, 2, 1},
+				maxSize:      15,
+				maxCode:      255,
 			},
 			false,
 		},
@@ -427,8 +428,9 @@ func TestNew(t *testing.T) {
 					0x909090909090909,
 					0x1111111111111111,
 				},
-				maxSize: 4294967295,
-				maxCode: 18446744073709551615,
+				lshiftsArray: []uint64{0, 64, 32, 16, 8, 4, 2, 1},
+				maxSize:      4294967295,
+				maxCode:      18446744073709551615,
 			},
 			false,
 		},
@@ -7,3 +7,30 @@ type DataItem interface {
 	Size() uint64
 	Values() []interface{}
 }
+
+type dataItem struct {
+	id     string
+	size   uint64
+	values []interface{}
+}
+
+// NewDataItem creates data item with given values.
+func NewDataItem(id string, size uint64, values ...interface{}) DataItem {
+	return dataItem{
+		id:     id,
+		size:   size,
+		values: values,
+	}
+}
+
+func (d dataItem) ID() string {
+	return d.id
+}
+
+func (d dataItem) Size() uint64 {
+	return d.size
+}
+
+func (d dataItem) Values() []interface{} {
+	return d.values
+}
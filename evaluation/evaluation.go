@@ -0,0 +1,224 @@
+// Package evaluation compares optimizers by the quality of partitions they produce.
+package evaluation
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/visheratin/balancer"
+	"github.com/visheratin/balancer/curve"
+)
+
+// Candidate is the optimizer under evaluation.
+type Candidate struct {
+	Name      string
+	Optimizer balancer.OptimizerFunc
+}
+
+// Result holds quality metrics of the partition.
+//
+// MaxRatio, MinRatio, StdDevRatio - statistics of the load-to-power ratio of nodes: the share
+// of the total load held by the node divided by its share of the total power. The ratio
+// of the perfectly balanced node is 1.
+//
+// CapacityViolations - number of nodes whose load exceeds their capacity.
+//
+// CellsMoved, BytesMoved - number and load of cells which change their node compared to
+// the current assignment.
+//
+// Locality - average share of neighbours of populated cells which belong to the same node.
+// Neighbours are the cells adjacent along one of the dimensions.
+//
+// Invalid - error returned by Space.ValidateGroups for the partition, Apply rejects
+// invalid partitions.
+//
+// Err - error returned by the optimizer, other metrics are not set if it is not nil.
+type Result struct {
+	Name               string
+	Runtime            time.Duration
+	MaxRatio           float64
+	MinRatio           float64
+	StdDevRatio        float64
+	CapacityViolations int
+	CellsMoved         int
+	BytesMoved         uint64
+	Locality           float64
+	Invalid            error
+	Err                error
+	Groups             []*balancer.CellGroup
+}
+
+// Current returns metrics of the current partition of the space.
+func Current(s *balancer.Space) Result {
+	cp := s.Clone()
+	cgs := cp.CellGroups()
+	o := newOwnership(cgs)
+	res := Result{
+		Name:   "current",
+		Groups: cgs,
+	}
+	measure(&res, cp, o, o)
+	return res
+}
+
+// Evaluate runs every optimizer on a separate clone of the space and returns metrics of the
+// partitions they produce. The space itself is not changed.
+func Evaluate(s *balancer.Space, cs ...Candidate) []Result {
+	res := make([]Result, len(cs))
+	for iter, c := range cs {
+		cp := s.Clone()
+		before := newOwnership(cp.CellGroups())
+		res[iter].Name = c.Name
+		start := time.Now()
+		cgs, err := c.Optimizer(cp)
+		res[iter].Runtime = time.Since(start)
+		if err != nil {
+			res[iter].Err = err
+			continue
+		}
+		res[iter].Groups = cgs
+		res[iter].Invalid = cp.ValidateGroups(cgs)
+		measure(&res[iter], cp, before, newOwnership(cgs))
+	}
+	return res
+}
+
+func measure(res *Result, s *balancer.Space, before, after *ownership) {
+	loads := map[string]float64{}
+	for id := range after.all {
+		loads[id] = 0
+	}
+	var totalLoad float64
+	populated := map[uint64]balancer.Node{}
+	for _, c := range s.Cells() {
+		n := after.owner(c.ID())
+		if n == nil || c.Load() == 0 {
+			continue
+		}
+		populated[c.ID()] = n
+		l := float64(c.Load())
+		loads[n.ID()] += l
+		totalLoad += l
+		if p := before.owner(c.ID()); p != nil && p.ID() != n.ID() {
+			res.CellsMoved++
+			res.BytesMoved += c.Load()
+		}
+	}
+	var totalPower float64
+	for id := range loads {
+		totalPower += after.all[id].Power().Get()
+	}
+	ratios := make([]float64, 0, len(loads))
+	for id, l := range loads {
+		n := after.all[id]
+		if l > n.Capacity().Get() {
+			res.CapacityViolations++
+		}
+		if totalLoad == 0 || n.Power().Get() == 0 {
+			continue
+		}
+		ratios = append(ratios, (l/totalLoad)/(n.Power().Get()/totalPower))
+	}
+	if len(ratios) > 0 {
+		res.MinRatio = math.Inf(1)
+		var sum float64
+		for _, r := range ratios {
+			res.MaxRatio = math.Max(res.MaxRatio, r)
+			res.MinRatio = math.Min(res.MinRatio, r)
+			sum += r
+		}
+		mean := sum / float64(len(ratios))
+		var dev float64
+		for _, r := range ratios {
+			dev += (r - mean) * (r - mean)
+		}
+		res.StdDevRatio = math.Sqrt(dev / float64(len(ratios)))
+	}
+	res.Locality = locality(populated, s.SFC(), after)
+}
+
+// locality returns average share of neighbours of populated cells which belong to
+// the same node.
+func locality(populated map[uint64]balancer.Node, sfc curve.Curve, o *ownership) float64 {
+	var sum float64
+	var count int
+	for id, n := range populated {
+		coords, err := sfc.Decode(id)
+		if err != nil {
+			continue
+		}
+		var same, total int
+		nb := make([]uint64, len(coords))
+		for d := range coords {
+			for _, delta := range []int64{-1, 1} {
+				if (delta < 0 && coords[d] == 0) || (delta > 0 && coords[d] == sfc.DimensionSize()) {
+					continue
+				}
+				// Encode may alter coordinates, so they are copied every time.
+				copy(nb, coords)
+				nb[d] = uint64(int64(nb[d]) + delta)
+				nID, err := sfc.Encode(nb)
+				if err != nil {
+					continue
+				}
+				total++
+				if nn := o.owner(nID); nn != nil && nn.ID() == n.ID() {
+					same++
+				}
+			}
+		}
+		if total > 0 {
+			sum += float64(same) / float64(total)
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
+
+// ownership maps cells to nodes by ranges of cell groups. Cells which are not covered by
+// any range belong to the group which holds them.
+type ownership struct {
+	ranges []balancer.Range
+	nodes  []balancer.Node
+	cells  map[uint64]balancer.Node
+	all    map[string]balancer.Node
+}
+
+func newOwnership(cgs []*balancer.CellGroup) *ownership {
+	o := &ownership{
+		cells: map[uint64]balancer.Node{},
+		all:   map[string]balancer.Node{},
+	}
+	sorted := make([]*balancer.CellGroup, 0, len(cgs))
+	for _, cg := range cgs {
+		o.all[cg.Node().ID()] = cg.Node()
+		if cg.Range().Len > 0 {
+			sorted = append(sorted, cg)
+		}
+		for id := range cg.Cells() {
+			o.cells[id] = cg.Node()
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Range().Min < sorted[j].Range().Min
+	})
+	for _, cg := range sorted {
+		o.ranges = append(o.ranges, cg.Range())
+		o.nodes = append(o.nodes, cg.Node())
+	}
+	return o
+}
+
+func (o *ownership) owner(cID uint64) balancer.Node {
+	idx := sort.Search(len(o.ranges), func(i int) bool {
+		return o.ranges[i].Max > cID
+	})
+	if idx < len(o.ranges) && o.ranges[idx].Min <= cID {
+		return o.nodes[idx]
+	}
+	return o.cells[cID]
+}
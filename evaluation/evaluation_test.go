@@ -0,0 +1,118 @@
+package evaluation
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/visheratin/balancer"
+	"github.com/visheratin/balancer/curve"
+	"github.com/visheratin/balancer/optimizer"
+	"github.com/visheratin/balancer/transform"
+	"github.com/visheratin/balancer/workload"
+)
+
+func newTestSpace(t *testing.T) *balancer.Space {
+	sfc, err := curve.NewCurve(curve.Hilbert, 2, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := []balancer.Node{
+		balancer.NewNode("n1", 1, 1e6),
+		balancer.NewNode("n2", 2, 1e6),
+		balancer.NewNode("n3", 1, 1000),
+	}
+	s, err := balancer.NewSpace(sfc, transform.SpaceTransform, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := workload.New(workload.RandomHotspots(3, 0.1), workload.Config{Seed: 1, MaxSize: 100, Format: workload.Geo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range w.Items(2000) {
+		if _, err := s.AddData(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func groupLoads(s *balancer.Space) map[string]uint64 {
+	res := map[string]uint64{}
+	for _, cg := range s.CellGroups() {
+		res[cg.ID()] = cg.TotalLoad()
+	}
+	return res
+}
+
+func TestEvaluate(t *testing.T) {
+	s := newTestSpace(t)
+	state, err := s.RoutingState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loads := groupLoads(s)
+
+	cur := Current(s)
+	if cur.CellsMoved != 0 || cur.BytesMoved != 0 {
+		t.Fatalf("current partition must not move cells, got %d", cur.CellsMoved)
+	}
+	if cur.Locality <= 0 || cur.Locality > 1 {
+		t.Fatalf("locality %v is out of range", cur.Locality)
+	}
+
+	failure := errors.New("failure")
+	rs := Evaluate(s,
+		Candidate{"power", optimizer.PowerOptimizer},
+		Candidate{"range", optimizer.RangeOptimizer},
+		Candidate{"power-range", optimizer.PowerRangeOptimizer},
+		Candidate{"failing", func(*balancer.Space) ([]*balancer.CellGroup, error) { return nil, failure }},
+	)
+	if len(rs) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(rs))
+	}
+	power := rs[0]
+	if power.Err != nil || power.Invalid != nil {
+		t.Fatalf("power optimizer failed: %v, %v", power.Err, power.Invalid)
+	}
+	if power.MaxRatio >= cur.MaxRatio || power.StdDevRatio >= cur.StdDevRatio {
+		t.Fatalf("power optimizer must improve balance: %+v vs %+v", power, cur)
+	}
+	if power.CellsMoved == 0 || power.BytesMoved == 0 {
+		t.Fatal("power optimizer must move cells")
+	}
+	if power.MinRatio > 1 || power.MaxRatio < 1 {
+		t.Fatalf("ratios must surround 1, got [%v, %v]", power.MinRatio, power.MaxRatio)
+	}
+	// n3 can hold only 1000 bytes.
+	if power.CapacityViolations != 1 {
+		t.Fatalf("expected 1 capacity violation, got %d", power.CapacityViolations)
+	}
+	if rs[3].Err != failure {
+		t.Fatalf("expected optimizer error, got %v", rs[3].Err)
+	}
+
+	after, err := s.RoutingState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(state, after) {
+		t.Fatal("evaluation changed routing of the space")
+	}
+	if !reflect.DeepEqual(loads, groupLoads(s)) {
+		t.Fatal("evaluation changed loads of cell groups")
+	}
+
+	buf := &bytes.Buffer{}
+	if err := WriteReport(buf, append([]Result{cur}, rs...)); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"current", "power-range", "error: failure"} {
+		if !strings.Contains(buf.String(), name) {
+			t.Fatalf("report does not contain %q:\n%s", name, buf.String())
+		}
+	}
+}
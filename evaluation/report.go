@@ -0,0 +1,27 @@
+package evaluation
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// WriteReport writes results as a table.
+func WriteReport(w io.Writer, rs []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "optimizer\tmax ratio\tmin ratio\tstddev\tover capacity\tcells moved\tbytes moved\tlocality\truntime\tvalid\t")
+	for _, r := range rs {
+		if r.Err != nil {
+			fmt.Fprintf(tw, "%s\terror: %v\t\t\t\t\t\t\t%s\t\t\n", r.Name, r.Err, r.Runtime)
+			continue
+		}
+		valid := "yes"
+		if r.Invalid != nil {
+			valid = "no"
+		}
+		fmt.Fprintf(tw, "%s\t%.3f\t%.3f\t%.3f\t%d\t%d\t%d\t%.3f\t%s\t%s\t\n",
+			r.Name, r.MaxRatio, r.MinRatio, r.StdDevRatio, r.CapacityViolations,
+			r.CellsMoved, r.BytesMoved, r.Locality, r.Runtime, valid)
+	}
+	return tw.Flush()
+}
@@ -1,6 +1,6 @@
 module github.com/visheratin/balancer
 
-go 1.14
+go 1.23.0
 
 require (
 	github.com/fogleman/gg v1.3.0
@@ -8,4 +8,13 @@ require (
 	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.5.1
 	golang.org/x/image v0.0.0-20200618115811-c13761719519 // indirect
+	google.golang.org/grpc v1.73.0
+	google.golang.org/protobuf v1.36.6
+)
+
+require (
+	golang.org/x/net v0.38.0 // indirect
+	golang.org/x/sys v0.31.0 // indirect
+	golang.org/x/text v0.23.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250324211829-b45e905df463 // indirect
 )
@@ -0,0 +1,413 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             v25.3.0
+// source: balancer.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Balancer_AddNode_FullMethodName           = "/balancer.Balancer/AddNode"
+	Balancer_RemoveNode_FullMethodName        = "/balancer.Balancer/RemoveNode"
+	Balancer_ListNodes_FullMethodName         = "/balancer.Balancer/ListNodes"
+	Balancer_AddData_FullMethodName           = "/balancer.Balancer/AddData"
+	Balancer_LocateData_FullMethodName        = "/balancer.Balancer/LocateData"
+	Balancer_Optimize_FullMethodName          = "/balancer.Balancer/Optimize"
+	Balancer_GetRoutingTable_FullMethodName   = "/balancer.Balancer/GetRoutingTable"
+	Balancer_WatchRoutingTable_FullMethodName = "/balancer.Balancer/WatchRoutingTable"
+)
+
+// BalancerClient is the client API for Balancer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Balancer distributes data items between nodes of the cluster.
+type BalancerClient interface {
+	// AddNode adds node to the balancer.
+	AddNode(ctx context.Context, in *AddNodeRequest, opts ...grpc.CallOption) (*Node, error)
+	// RemoveNode removes node from the balancer and redistributes its cells.
+	RemoveNode(ctx context.Context, in *RemoveNodeRequest, opts ...grpc.CallOption) (*RemoveNodeResponse, error)
+	// ListNodes returns all nodes of the balancer.
+	ListNodes(ctx context.Context, in *ListNodesRequest, opts ...grpc.CallOption) (*ListNodesResponse, error)
+	// AddData loads data item into the balancer and returns its route.
+	AddData(ctx context.Context, in *DataRequest, opts ...grpc.CallOption) (*Route, error)
+	// LocateData returns the route of the data item.
+	LocateData(ctx context.Context, in *DataRequest, opts ...grpc.CallOption) (*Route, error)
+	// Optimize computes new cell groups and optionally applies them.
+	Optimize(ctx context.Context, in *OptimizeRequest, opts ...grpc.CallOption) (*RoutingTable, error)
+	// GetRoutingTable returns current routing table.
+	GetRoutingTable(ctx context.Context, in *GetRoutingTableRequest, opts ...grpc.CallOption) (*RoutingTable, error)
+	// WatchRoutingTable sends current routing table and then a new one every time
+	// cell groups or nodes of the balancer change.
+	WatchRoutingTable(ctx context.Context, in *WatchRoutingTableRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RoutingTable], error)
+}
+
+type balancerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBalancerClient(cc grpc.ClientConnInterface) BalancerClient {
+	return &balancerClient{cc}
+}
+
+func (c *balancerClient) AddNode(ctx context.Context, in *AddNodeRequest, opts ...grpc.CallOption) (*Node, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Node)
+	err := c.cc.Invoke(ctx, Balancer_AddNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *balancerClient) RemoveNode(ctx context.Context, in *RemoveNodeRequest, opts ...grpc.CallOption) (*RemoveNodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveNodeResponse)
+	err := c.cc.Invoke(ctx, Balancer_RemoveNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *balancerClient) ListNodes(ctx context.Context, in *ListNodesRequest, opts ...grpc.CallOption) (*ListNodesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListNodesResponse)
+	err := c.cc.Invoke(ctx, Balancer_ListNodes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *balancerClient) AddData(ctx context.Context, in *DataRequest, opts ...grpc.CallOption) (*Route, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Route)
+	err := c.cc.Invoke(ctx, Balancer_AddData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *balancerClient) LocateData(ctx context.Context, in *DataRequest, opts ...grpc.CallOption) (*Route, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Route)
+	err := c.cc.Invoke(ctx, Balancer_LocateData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *balancerClient) Optimize(ctx context.Context, in *OptimizeRequest, opts ...grpc.CallOption) (*RoutingTable, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RoutingTable)
+	err := c.cc.Invoke(ctx, Balancer_Optimize_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *balancerClient) GetRoutingTable(ctx context.Context, in *GetRoutingTableRequest, opts ...grpc.CallOption) (*RoutingTable, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RoutingTable)
+	err := c.cc.Invoke(ctx, Balancer_GetRoutingTable_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *balancerClient) WatchRoutingTable(ctx context.Context, in *WatchRoutingTableRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RoutingTable], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Balancer_ServiceDesc.Streams[0], Balancer_WatchRoutingTable_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRoutingTableRequest, RoutingTable]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Balancer_WatchRoutingTableClient = grpc.ServerStreamingClient[RoutingTable]
+
+// BalancerServer is the server API for Balancer service.
+// All implementations must embed UnimplementedBalancerServer
+// for forward compatibility.
+//
+// Balancer distributes data items between nodes of the cluster.
+type BalancerServer interface {
+	// AddNode adds node to the balancer.
+	AddNode(context.Context, *AddNodeRequest) (*Node, error)
+	// RemoveNode removes node from the balancer and redistributes its cells.
+	RemoveNode(context.Context, *RemoveNodeRequest) (*RemoveNodeResponse, error)
+	// ListNodes returns all nodes of the balancer.
+	ListNodes(context.Context, *ListNodesRequest) (*ListNodesResponse, error)
+	// AddData loads data item into the balancer and returns its route.
+	AddData(context.Context, *DataRequest) (*Route, error)
+	// LocateData returns the route of the data item.
+	LocateData(context.Context, *DataRequest) (*Route, error)
+	// Optimize computes new cell groups and optionally applies them.
+	Optimize(context.Context, *OptimizeRequest) (*RoutingTable, error)
+	// GetRoutingTable returns current routing table.
+	GetRoutingTable(context.Context, *GetRoutingTableRequest) (*RoutingTable, error)
+	// WatchRoutingTable sends current routing table and then a new one every time
+	// cell groups or nodes of the balancer change.
+	WatchRoutingTable(*WatchRoutingTableRequest, grpc.ServerStreamingServer[RoutingTable]) error
+	mustEmbedUnimplementedBalancerServer()
+}
+
+// UnimplementedBalancerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBalancerServer struct{}
+
+func (UnimplementedBalancerServer) AddNode(context.Context, *AddNodeRequest) (*Node, error) {
+	return nil, status.Error(codes.Unimplemented, "method AddNode not implemented")
+}
+func (UnimplementedBalancerServer) RemoveNode(context.Context, *RemoveNodeRequest) (*RemoveNodeResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RemoveNode not implemented")
+}
+func (UnimplementedBalancerServer) ListNodes(context.Context, *ListNodesRequest) (*ListNodesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListNodes not implemented")
+}
+func (UnimplementedBalancerServer) AddData(context.Context, *DataRequest) (*Route, error) {
+	return nil, status.Error(codes.Unimplemented, "method AddData not implemented")
+}
+func (UnimplementedBalancerServer) LocateData(context.Context, *DataRequest) (*Route, error) {
+	return nil, status.Error(codes.Unimplemented, "method LocateData not implemented")
+}
+func (UnimplementedBalancerServer) Optimize(context.Context, *OptimizeRequest) (*RoutingTable, error) {
+	return nil, status.Error(codes.Unimplemented, "method Optimize not implemented")
+}
+func (UnimplementedBalancerServer) GetRoutingTable(context.Context, *GetRoutingTableRequest) (*RoutingTable, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetRoutingTable not implemented")
+}
+func (UnimplementedBalancerServer) WatchRoutingTable(*WatchRoutingTableRequest, grpc.ServerStreamingServer[RoutingTable]) error {
+	return status.Error(codes.Unimplemented, "method WatchRoutingTable not implemented")
+}
+func (UnimplementedBalancerServer) mustEmbedUnimplementedBalancerServer() {}
+func (UnimplementedBalancerServer) testEmbeddedByValue()                  {}
+
+// UnsafeBalancerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BalancerServer will
+// result in compilation errors.
+type UnsafeBalancerServer interface {
+	mustEmbedUnimplementedBalancerServer()
+}
+
+func RegisterBalancerServer(s grpc.ServiceRegistrar, srv BalancerServer) {
+	// If the following call panics, it indicates UnimplementedBalancerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Balancer_ServiceDesc, srv)
+}
+
+func _Balancer_AddNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BalancerServer).AddNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Balancer_AddNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BalancerServer).AddNode(ctx, req.(*AddNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Balancer_RemoveNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BalancerServer).RemoveNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Balancer_RemoveNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BalancerServer).RemoveNode(ctx, req.(*RemoveNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Balancer_ListNodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListNodesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BalancerServer).ListNodes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Balancer_ListNodes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BalancerServer).ListNodes(ctx, req.(*ListNodesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Balancer_AddData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BalancerServer).AddData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Balancer_AddData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BalancerServer).AddData(ctx, req.(*DataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Balancer_LocateData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BalancerServer).LocateData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Balancer_LocateData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BalancerServer).LocateData(ctx, req.(*DataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Balancer_Optimize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OptimizeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BalancerServer).Optimize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Balancer_Optimize_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BalancerServer).Optimize(ctx, req.(*OptimizeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Balancer_GetRoutingTable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRoutingTableRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BalancerServer).GetRoutingTable(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Balancer_GetRoutingTable_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BalancerServer).GetRoutingTable(ctx, req.(*GetRoutingTableRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Balancer_WatchRoutingTable_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRoutingTableRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BalancerServer).WatchRoutingTable(m, &grpc.GenericServerStream[WatchRoutingTableRequest, RoutingTable]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Balancer_WatchRoutingTableServer = grpc.ServerStreamingServer[RoutingTable]
+
+// Balancer_ServiceDesc is the grpc.ServiceDesc for Balancer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Balancer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "balancer.Balancer",
+	HandlerType: (*BalancerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddNode",
+			Handler:    _Balancer_AddNode_Handler,
+		},
+		{
+			MethodName: "RemoveNode",
+			Handler:    _Balancer_RemoveNode_Handler,
+		},
+		{
+			MethodName: "ListNodes",
+			Handler:    _Balancer_ListNodes_Handler,
+		},
+		{
+			MethodName: "AddData",
+			Handler:    _Balancer_AddData_Handler,
+		},
+		{
+			MethodName: "LocateData",
+			Handler:    _Balancer_LocateData_Handler,
+		},
+		{
+			MethodName: "Optimize",
+			Handler:    _Balancer_Optimize_Handler,
+		},
+		{
+			MethodName: "GetRoutingTable",
+			Handler:    _Balancer_GetRoutingTable_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchRoutingTable",
+			Handler:       _Balancer_WatchRoutingTable_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "balancer.proto",
+}
@@ -0,0 +1,201 @@
+// Package grpcapi implements gRPC service exposing the balancer.
+package grpcapi
+
+//go:generate protoc --go_out=pb --go_opt=paths=source_relative --go-grpc_out=pb --go-grpc_opt=paths=source_relative balancer.proto
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/visheratin/balancer"
+	"github.com/visheratin/balancer/grpcapi/pb"
+)
+
+// Server implements pb.BalancerServer.
+type Server struct {
+	pb.UnimplementedBalancerServer
+	// mu serializes operations changing nodes and cell groups of the balancer.
+	mu sync.Mutex
+	b  *balancer.Balancer
+}
+
+// NewServer creates gRPC server for the balancer. It should be registered with
+// pb.RegisterBalancerServer.
+func NewServer(b *balancer.Balancer) *Server {
+	return &Server{
+		b: b,
+	}
+}
+
+func (s *Server) AddNode(ctx context.Context, req *pb.AddNodeRequest) (*pb.Node, error) {
+	n := req.GetNode()
+	if n.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "node id is not set")
+	}
+	if n.GetPower() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "node power must be greater than 0")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.b.AddNode(balancer.NewNode(n.GetId(), n.GetPower(), n.GetCapacity()), req.GetOptimize()); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return n, nil
+}
+
+func (s *Server) RemoveNode(ctx context.Context, req *pb.RemoveNodeRequest) (*pb.RemoveNodeResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.b.GetNode(req.GetId()); !ok {
+		return nil, status.Errorf(codes.NotFound, "node(%s) not found", req.GetId())
+	}
+	if err := s.b.RemoveNode(req.GetId()); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.RemoveNodeResponse{}, nil
+}
+
+func (s *Server) ListNodes(ctx context.Context, req *pb.ListNodesRequest) (*pb.ListNodesResponse, error) {
+	ns := s.b.Nodes()
+	res := &pb.ListNodesResponse{
+		Nodes: make([]*pb.Node, len(ns)),
+	}
+	for iter, n := range ns {
+		res.Nodes[iter] = &pb.Node{
+			Id:       n.ID(),
+			Power:    n.Power().Get(),
+			Capacity: n.Capacity().Get(),
+		}
+	}
+	return res, nil
+}
+
+func (s *Server) AddData(ctx context.Context, req *pb.DataRequest) (*pb.Route, error) {
+	d, err := dataItem(req)
+	if err != nil {
+		return nil, err
+	}
+	r, err := s.b.AddDataRoute(d)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return route(r), nil
+}
+
+func (s *Server) LocateData(ctx context.Context, req *pb.DataRequest) (*pb.Route, error) {
+	d, err := dataItem(req)
+	if err != nil {
+		return nil, err
+	}
+	r, err := s.b.LocateData(d)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return route(r), nil
+}
+
+func (s *Server) Optimize(ctx context.Context, req *pb.OptimizeRequest) (*pb.RoutingTable, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cgs, err := s.b.Optimize()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if !req.GetApply() {
+		return &pb.RoutingTable{
+			Epoch:  s.b.Epoch(),
+			Groups: groups(cgs),
+		}, nil
+	}
+	if err := s.b.Apply(cgs); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return s.routingTable()
+}
+
+func (s *Server) GetRoutingTable(ctx context.Context, req *pb.GetRoutingTableRequest) (*pb.RoutingTable, error) {
+	return s.routingTable()
+}
+
+func (s *Server) WatchRoutingTable(req *pb.WatchRoutingTableRequest, stream pb.Balancer_WatchRoutingTableServer) error {
+	ch, stop := s.b.Watch()
+	defer stop()
+	for {
+		rt, err := s.routingTable()
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(rt); err != nil {
+			return err
+		}
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ch:
+		}
+	}
+}
+
+func route(r balancer.Route) *pb.Route {
+	res := &pb.Route{
+		Node:   r.Node.ID(),
+		CellId: r.CellID,
+		Epoch:  r.Epoch,
+	}
+	for _, n := range r.Write {
+		res.Write = append(res.Write, n.ID())
+	}
+	return res
+}
+
+func (s *Server) routingTable() (*pb.RoutingTable, error) {
+	rs, err := s.b.Space().RoutingState()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	state, err := rs.MarshalBinary()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.RoutingTable{
+		Epoch:  rs.Epoch,
+		Groups: groups(s.b.Space().CellGroups()),
+		State:  state,
+	}, nil
+}
+
+func groups(cgs []*balancer.CellGroup) []*pb.Group {
+	res := make([]*pb.Group, len(cgs))
+	for iter, cg := range cgs {
+		r := cg.Range()
+		res[iter] = &pb.Group{
+			Node: cg.ID(),
+			Min:  r.Min,
+			Max:  r.Max,
+			Load: cg.TotalLoad(),
+		}
+	}
+	return res
+}
+
+func dataItem(req *pb.DataRequest) (balancer.DataItem, error) {
+	values := make([]interface{}, len(req.GetValues()))
+	for iter, v := range req.GetValues() {
+		switch k := v.GetKind().(type) {
+		case *pb.Value_Number:
+			values[iter] = k.Number
+		case *pb.Value_Text:
+			values[iter] = k.Text
+		case *pb.Value_Integer:
+			values[iter] = k.Integer
+		case *pb.Value_Flag:
+			values[iter] = k.Flag
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "value %d is not set", iter)
+		}
+	}
+	return balancer.NewDataItem(req.GetId(), req.GetSize(), values...), nil
+}
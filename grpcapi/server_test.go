@@ -0,0 +1,158 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/visheratin/balancer"
+	"github.com/visheratin/balancer/curve"
+	"github.com/visheratin/balancer/grpcapi/pb"
+	"github.com/visheratin/balancer/optimizer"
+	"github.com/visheratin/balancer/transform"
+)
+
+func newTestClient(t *testing.T) pb.BalancerClient {
+	nodes := []balancer.Node{
+		balancer.NewNode("n1", 1, 1000),
+		balancer.NewNode("n2", 1, 1000),
+	}
+	b, err := balancer.NewBalancer(curve.Hilbert, 2, 64, transform.SpaceTransform, optimizer.RangeOptimizer, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	pb.RegisterBalancerServer(gs, NewServer(b))
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewBalancerClient(conn)
+}
+
+func geoValues(lat, lon float64) []*pb.Value {
+	return []*pb.Value{
+		{Kind: &pb.Value_Number{Number: lat}},
+		{Kind: &pb.Value_Number{Number: lon}},
+	}
+}
+
+func TestServer(t *testing.T) {
+	c := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	route, err := c.AddData(ctx, &pb.DataRequest{Id: "d1", Size: 10, Values: geoValues(55.75, 37.62)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	located, err := c.LocateData(ctx, &pb.DataRequest{Id: "d1", Values: geoValues(55.75, 37.62)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if located.GetNode() != route.GetNode() || located.GetCellId() != route.GetCellId() {
+		t.Errorf("LocateData() = %v, want %v", located, route)
+	}
+	_, err = c.LocateData(ctx, &pb.DataRequest{Id: "d2", Values: []*pb.Value{{Kind: &pb.Value_Text{Text: "bad"}}}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("LocateData() with invalid values error = %v, want %v", err, codes.InvalidArgument)
+	}
+
+	if _, err := c.AddNode(ctx, &pb.AddNodeRequest{Node: &pb.Node{Id: "n3", Power: 2, Capacity: 500}, Optimize: true}); err != nil {
+		t.Fatal(err)
+	}
+	nodes, err := c.ListNodes(ctx, &pb.ListNodesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes.GetNodes()) != 3 {
+		t.Errorf("ListNodes() = %v, want 3 nodes", nodes.GetNodes())
+	}
+	if _, err := c.RemoveNode(ctx, &pb.RemoveNodeRequest{Id: "n4"}); status.Code(err) != codes.NotFound {
+		t.Errorf("RemoveNode() of unknown node error = %v, want %v", err, codes.NotFound)
+	}
+
+	rt, err := c.Optimize(ctx, &pb.OptimizeRequest{Apply: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rt.GetGroups()) != 3 {
+		t.Errorf("Optimize() = %v, want 3 groups", rt.GetGroups())
+	}
+	r, err := balancer.NewRouter(rt.GetState(), transform.SpaceTransform)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := r.Locate(balancer.NewDataItem("d1", 10, 55.75, 37.62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	located, err = c.LocateData(ctx, &pb.DataRequest{Id: "d1", Values: geoValues(55.75, 37.62)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node != located.GetNode() {
+		t.Errorf("Router.Locate() = %s, want %s", node, located.GetNode())
+	}
+}
+
+func TestServer_WatchRoutingTable(t *testing.T) {
+	c := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := c.WatchRoutingTable(ctx, &pb.WatchRoutingTableRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.GetGroups()) != 2 {
+		t.Fatalf("WatchRoutingTable() first table = %v, want 2 groups", first.GetGroups())
+	}
+	if _, err := c.AddNode(ctx, &pb.AddNodeRequest{Node: &pb.Node{Id: "n3", Power: 1, Capacity: 500}, Optimize: true}); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		rt, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rt.GetEpoch() <= first.GetEpoch() {
+			t.Fatalf("WatchRoutingTable() epoch = %d, want greater than %d", rt.GetEpoch(), first.GetEpoch())
+		}
+		if len(rt.GetGroups()) == 3 {
+			break
+		}
+	}
+	if _, err := c.RemoveNode(ctx, &pb.RemoveNodeRequest{Id: "n1"}); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		rt, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rt.GetGroups()) == 2 {
+			break
+		}
+	}
+}
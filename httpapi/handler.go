@@ -0,0 +1,273 @@
+// Package httpapi exposes the balancer as HTTP/JSON service.
+//
+// Endpoints:
+//
+//	GET    /nodes         - list nodes
+//	POST   /nodes         - add node, body NodeRequest
+//	DELETE /nodes/{id}    - remove node
+//	POST   /data          - add data item, body DataRequest
+//	POST   /locate        - locate data item, body DataRequest
+//	POST   /optimize      - optimize cell groups, body OptimizeRequest
+//	GET    /groups        - current cell groups with their ranges and loads
+//
+// Request bodies are limited to 1 MiB. Errors are returned as ErrorResponse with status 400
+// for invalid requests and partitions, 404 for unknown nodes, 409 while the migration is
+// in progress and 500 for other failures.
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/visheratin/balancer"
+)
+
+// maxBodySize is the maximum size of the request body in bytes.
+const maxBodySize = 1 << 20
+
+// Handler serves HTTP requests to the balancer.
+type Handler struct {
+	// mu serializes operations changing nodes and cell groups of the balancer.
+	mu  sync.Mutex
+	b   *balancer.Balancer
+	mux *http.ServeMux
+}
+
+// NewHandler creates HTTP handler for the balancer.
+func NewHandler(b *balancer.Balancer) *Handler {
+	h := &Handler{
+		b:   b,
+		mux: http.NewServeMux(),
+	}
+	h.mux.HandleFunc("/nodes", h.nodes)
+	h.mux.HandleFunc("/nodes/", h.node)
+	h.mux.HandleFunc("/data", h.addData)
+	h.mux.HandleFunc("/locate", h.locateData)
+	h.mux.HandleFunc("/optimize", h.optimize)
+	h.mux.HandleFunc("/groups", h.groups)
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) nodes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ns := h.b.Nodes()
+		res := make([]NodeResponse, len(ns))
+		for iter := range ns {
+			res[iter] = nodeResponse(ns[iter])
+		}
+		writeJSON(w, http.StatusOK, res)
+	case http.MethodPost:
+		req := NodeRequest{}
+		if !readJSON(w, r, &req) {
+			return
+		}
+		if req.ID == "" {
+			writeError(w, http.StatusBadRequest, "node id is not set")
+			return
+		}
+		if req.Power <= 0 {
+			writeError(w, http.StatusBadRequest, "node power must be greater than 0")
+			return
+		}
+		n := balancer.NewNode(req.ID, req.Power, req.Capacity)
+		h.mu.Lock()
+		err := h.b.AddNode(n, req.Optimize)
+		h.mu.Unlock()
+		if err != nil {
+			writeError(w, errorStatus(err, http.StatusInternalServerError), err.Error())
+			return
+		}
+		writeJSON(w, http.StatusCreated, nodeResponse(n))
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+func (h *Handler) node(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/nodes/")
+	if id == "" || strings.Contains(id, "/") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		n, ok := h.b.GetNode(id)
+		if !ok {
+			writeError(w, http.StatusNotFound, "node not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, nodeResponse(n))
+	case http.MethodDelete:
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.b.GetNode(id); !ok {
+			writeError(w, http.StatusNotFound, "node not found")
+			return
+		}
+		if err := h.b.RemoveNode(id); err != nil {
+			writeError(w, errorStatus(err, http.StatusInternalServerError), err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodDelete)
+	}
+}
+
+func (h *Handler) addData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	req := DataRequest{}
+	if !readJSON(w, r, &req) {
+		return
+	}
+	route, err := h.b.AddDataRoute(balancer.NewDataItem(req.ID, req.Size, req.Values...))
+	if err != nil {
+		writeError(w, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, routeResponse(route))
+}
+
+func (h *Handler) locateData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	req := DataRequest{}
+	if !readJSON(w, r, &req) {
+		return
+	}
+	route, err := h.b.LocateData(balancer.NewDataItem(req.ID, req.Size, req.Values...))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, routeResponse(route))
+}
+
+func (h *Handler) optimize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	req := OptimizeRequest{}
+	if !readJSON(w, r, &req) {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	cgs, err := h.b.Optimize()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if req.Apply {
+		if err := h.b.Apply(cgs); err != nil {
+			writeError(w, errorStatus(err, http.StatusConflict), err.Error())
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, groupsResponse(h.b.Epoch(), cgs))
+}
+
+func (h *Handler) groups(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	writeJSON(w, http.StatusOK, groupsResponse(h.b.Epoch(), h.b.Space().CellGroups()))
+}
+
+func nodeResponse(n balancer.Node) NodeResponse {
+	return NodeResponse{
+		ID:       n.ID(),
+		Power:    n.Power().Get(),
+		Capacity: n.Capacity().Get(),
+	}
+}
+
+func routeResponse(r balancer.Route) RouteResponse {
+	res := RouteResponse{
+		Node:   r.Node.ID(),
+		Write:  make([]string, len(r.Write)),
+		CellID: r.CellID,
+		Epoch:  r.Epoch,
+	}
+	for iter := range r.Write {
+		res.Write[iter] = r.Write[iter].ID()
+	}
+	return res
+}
+
+func groupsResponse(epoch uint64, cgs []*balancer.CellGroup) GroupsResponse {
+	res := GroupsResponse{
+		Epoch:  epoch,
+		Groups: make([]GroupResponse, len(cgs)),
+	}
+	for iter, cg := range cgs {
+		r := cg.Range()
+		res.Groups[iter] = GroupResponse{
+			Node:  cg.ID(),
+			Min:   r.Min,
+			Max:   r.Max,
+			Load:  cg.TotalLoad(),
+			Cells: len(cg.Cells()),
+		}
+	}
+	return res
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		status := http.StatusBadRequest
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, status, "invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// errorStatus returns HTTP status of the error returned by the balancer, def is returned
+// for errors which are not recognized.
+func errorStatus(err error, def int) int {
+	var pe *balancer.PartitionError
+	switch {
+	case errors.Is(err, balancer.ErrMigrationInProgress):
+		return http.StatusConflict
+	case errors.Is(err, balancer.ErrNodeNotFound):
+		return http.StatusNotFound
+	case errors.As(err, &pe):
+		return http.StatusBadRequest
+	}
+	return def
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, ErrorResponse{Error: msg})
+}
+
+func methodNotAllowed(w http.ResponseWriter, methods ...string) {
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+}
@@ -0,0 +1,162 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/visheratin/balancer"
+	"github.com/visheratin/balancer/curve"
+	"github.com/visheratin/balancer/optimizer"
+	"github.com/visheratin/balancer/transform"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	srv, _ := newTestBalancerServer(t, optimizer.RangeOptimizer)
+	return srv
+}
+
+func newTestBalancerServer(t *testing.T, of balancer.OptimizerFunc) (*httptest.Server, *balancer.Balancer) {
+	nodes := []balancer.Node{
+		balancer.NewNode("n1", 1, 1000),
+		balancer.NewNode("n2", 1, 1000),
+	}
+	b, err := balancer.NewBalancer(curve.Hilbert, 2, 64, transform.SpaceTransform, of, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(NewHandler(b))
+	t.Cleanup(srv.Close)
+	return srv, b
+}
+
+func do(t *testing.T, srv *httptest.Server, method, path string, body, resp interface{}) int {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, srv.URL+path, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if resp != nil && res.StatusCode < 300 {
+		if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return res.StatusCode
+}
+
+func TestHandler(t *testing.T) {
+	srv := newTestServer(t)
+
+	var route RouteResponse
+	status := do(t, srv, http.MethodPost, "/data", DataRequest{ID: "d1", Size: 10, Values: []interface{}{55.75, 37.62}}, &route)
+	if status != http.StatusOK {
+		t.Fatalf("POST /data status = %d", status)
+	}
+	var located RouteResponse
+	status = do(t, srv, http.MethodPost, "/locate", DataRequest{ID: "d1", Values: []interface{}{55.75, 37.62}}, &located)
+	if status != http.StatusOK || !reflect.DeepEqual(located, route) {
+		t.Fatalf("POST /locate = %d %+v, want %+v", status, located, route)
+	}
+	if len(located.Write) != 1 || located.Write[0] != located.Node {
+		t.Fatalf("POST /locate write nodes = %v, want [%s]", located.Write, located.Node)
+	}
+	if status = do(t, srv, http.MethodPost, "/locate", DataRequest{ID: "d2", Values: []interface{}{"bad"}}, nil); status != http.StatusBadRequest {
+		t.Errorf("POST /locate with invalid values status = %d, want %d", status, http.StatusBadRequest)
+	}
+
+	var n NodeResponse
+	status = do(t, srv, http.MethodPost, "/nodes", NodeRequest{ID: "n3", Power: 2, Capacity: 500, Optimize: true}, &n)
+	if status != http.StatusCreated || n.ID != "n3" || n.Power != 2 {
+		t.Fatalf("POST /nodes = %d %+v", status, n)
+	}
+	var nodes []NodeResponse
+	if status = do(t, srv, http.MethodGet, "/nodes", nil, &nodes); status != http.StatusOK || len(nodes) != 3 {
+		t.Fatalf("GET /nodes = %d %+v", status, nodes)
+	}
+
+	var groups GroupsResponse
+	if status = do(t, srv, http.MethodGet, "/groups", nil, &groups); status != http.StatusOK || len(groups.Groups) != 3 {
+		t.Fatalf("GET /groups = %d %+v", status, groups)
+	}
+	var load uint64
+	for _, g := range groups.Groups {
+		load += g.Load
+	}
+	if load != 10 {
+		t.Errorf("GET /groups total load = %d, want 10", load)
+	}
+	if groups.Epoch <= route.Epoch {
+		t.Errorf("GET /groups epoch = %d, want greater than %d", groups.Epoch, route.Epoch)
+	}
+
+	if status = do(t, srv, http.MethodDelete, "/nodes/n1", nil, nil); status != http.StatusNoContent {
+		t.Fatalf("DELETE /nodes/n1 status = %d", status)
+	}
+	if status = do(t, srv, http.MethodDelete, "/nodes/n1", nil, nil); status != http.StatusNotFound {
+		t.Errorf("DELETE /nodes/n1 again status = %d, want %d", status, http.StatusNotFound)
+	}
+
+	if status = do(t, srv, http.MethodPost, "/optimize", OptimizeRequest{Apply: true}, &groups); status != http.StatusOK || len(groups.Groups) != 2 {
+		t.Fatalf("POST /optimize = %d %+v", status, groups)
+	}
+	if status = do(t, srv, http.MethodPut, "/groups", nil, nil); status != http.StatusMethodNotAllowed {
+		t.Errorf("PUT /groups status = %d, want %d", status, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	srv, b := newTestBalancerServer(t, optimizer.RangeOptimizer)
+	for lat := -60.0; lat <= 60; lat += 20 {
+		if status := do(t, srv, http.MethodPost, "/data", DataRequest{ID: "d", Size: 10, Values: []interface{}{lat, lat * 2}}, nil); status != http.StatusOK {
+			t.Fatalf("POST /data status = %d", status)
+		}
+	}
+	if err := b.AddNode(balancer.NewNode("n3", 1, 1000), false); err != nil {
+		t.Fatal(err)
+	}
+	cgs, err := b.Optimize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := b.StageApply(cgs, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Done() {
+		t.Fatal("migration must have steps")
+	}
+	if status := do(t, srv, http.MethodPost, "/nodes", NodeRequest{ID: "n4", Power: 1, Optimize: true}, nil); status != http.StatusConflict {
+		t.Errorf("POST /nodes during migration status = %d, want %d", status, http.StatusConflict)
+	}
+	if status := do(t, srv, http.MethodDelete, "/nodes/n1", nil, nil); status != http.StatusConflict {
+		t.Errorf("DELETE /nodes/n1 during migration status = %d, want %d", status, http.StatusConflict)
+	}
+
+	body := DataRequest{ID: strings.Repeat("d", maxBodySize)}
+	if status := do(t, srv, http.MethodPost, "/data", body, nil); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("POST /data with large body status = %d, want %d", status, http.StatusRequestEntityTooLarge)
+	}
+
+	// The optimizer gives the whole space to the first node, so the partition misses other nodes.
+	srv, _ = newTestBalancerServer(t, func(s *balancer.Space) ([]*balancer.CellGroup, error) {
+		cg := balancer.NewCellGroup(s.CellGroups()[0].Node())
+		return []*balancer.CellGroup{cg}, cg.SetRange(0, s.TotalCells())
+	})
+	if status := do(t, srv, http.MethodPost, "/nodes", NodeRequest{ID: "n3", Power: 1, Optimize: true}, nil); status != http.StatusBadRequest {
+		t.Errorf("POST /nodes with invalid partition status = %d, want %d", status, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,66 @@
+package httpapi
+
+// NodeRequest is the body of the request adding a node to the balancer.
+//
+// Optimize - whether cells should be redistributed between nodes after adding the node.
+type NodeRequest struct {
+	ID       string  `json:"id"`
+	Power    float64 `json:"power"`
+	Capacity float64 `json:"capacity"`
+	Optimize bool    `json:"optimize"`
+}
+
+// NodeResponse describes a node of the balancer.
+type NodeResponse struct {
+	ID       string  `json:"id"`
+	Power    float64 `json:"power"`
+	Capacity float64 `json:"capacity"`
+}
+
+// DataRequest is the body of requests adding and locating data items.
+// Values are passed to the transform function of the balancer, JSON numbers
+// are decoded as float64.
+type DataRequest struct {
+	ID     string        `json:"id"`
+	Size   uint64        `json:"size"`
+	Values []interface{} `json:"values"`
+}
+
+// RouteResponse describes the placement of the data item.
+//
+// Node - node which receives reads of the data item.
+//
+// Write - nodes which receive writes, both old and new nodes while the cell is being moved.
+type RouteResponse struct {
+	Node   string   `json:"node"`
+	Write  []string `json:"write"`
+	CellID uint64   `json:"cell_id"`
+	Epoch  uint64   `json:"epoch"`
+}
+
+// OptimizeRequest is the body of the request triggering optimization.
+//
+// Apply - whether optimized cell groups should replace current ones.
+type OptimizeRequest struct {
+	Apply bool `json:"apply"`
+}
+
+// GroupResponse describes a cell group of the balancer.
+type GroupResponse struct {
+	Node  string `json:"node"`
+	Min   uint64 `json:"min"`
+	Max   uint64 `json:"max"`
+	Load  uint64 `json:"load"`
+	Cells int    `json:"cells"`
+}
+
+// GroupsResponse describes the partition of the space into cell groups.
+type GroupsResponse struct {
+	Epoch  uint64          `json:"epoch"`
+	Groups []GroupResponse `json:"groups"`
+}
+
+// ErrorResponse is returned with all unsuccessful responses.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,150 @@
+package balancer
+
+import (
+	"sort"
+)
+
+// NodeImpact describes how the new partition changes the node.
+//
+// Before, After - ranges of cells held by the node in the current and the new partition.
+//
+// BytesIn, BytesOut - load of cells which move to and from the node.
+//
+// RatioBefore, RatioAfter - the share of the total load held by the node divided by its share
+// of the total power of the partition. The ratio of the perfectly balanced node is 1.
+//
+// OverCapacity - the load of the node in the new partition exceeds its capacity.
+type NodeImpact struct {
+	Node         Node
+	Before       []Range
+	After        []Range
+	BytesIn      uint64
+	BytesOut     uint64
+	LoadBefore   uint64
+	LoadAfter    uint64
+	RatioBefore  float64
+	RatioAfter   float64
+	OverCapacity bool
+}
+
+// Impact describes what would change if cell groups were applied to the space.
+//
+// Nodes - impact for every node of the current and the new partition, sorted by node ID.
+//
+// Invalid - error returned by ValidateGroups, Apply rejects invalid partitions.
+type Impact struct {
+	Nodes      []NodeImpact
+	CellsMoved int
+	BytesMoved uint64
+	Invalid    error
+}
+
+// OverCapacity returns IDs of nodes whose load would exceed their capacity.
+func (i Impact) OverCapacity() []string {
+	var res []string
+	for _, n := range i.Nodes {
+		if n.OverCapacity {
+			res = append(res, n.Node.ID())
+		}
+	}
+	return res
+}
+
+// DryRun computes the impact of applying cell groups without changing the space.
+// Cells are assigned to new groups the same way as SetGroups does.
+func (s *Space) DryRun(cgs []*CellGroup) Impact {
+	res := Impact{
+		Invalid: s.ValidateGroups(cgs),
+	}
+	rt := newRoutingTable(cgs)
+	held := map[uint64]*CellGroup{}
+	for _, cg := range cgs {
+		for id := range cg.Cells() {
+			held[id] = cg
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	nodes := map[string]*NodeImpact{}
+	impact := func(n Node) *NodeImpact {
+		ni, ok := nodes[n.ID()]
+		if !ok {
+			ni = &NodeImpact{Node: n}
+			nodes[n.ID()] = ni
+		}
+		return ni
+	}
+	cur := s.routingTable()
+	for iter := range cur.mins {
+		ni := impact(cur.nodes[iter])
+		ni.Before = append(ni.Before, Range{Min: cur.mins[iter], Max: cur.maxs[iter], Len: cur.maxs[iter] - cur.mins[iter]})
+	}
+	for _, cg := range s.cgs {
+		impact(cg.Node())
+	}
+	for iter := range rt.mins {
+		ni := impact(rt.nodes[iter])
+		ni.After = append(ni.After, Range{Min: rt.mins[iter], Max: rt.maxs[iter], Len: rt.maxs[iter] - rt.mins[iter]})
+	}
+	for _, cg := range cgs {
+		impact(cg.Node())
+	}
+
+	for id, c := range s.cells {
+		c.mu.Lock()
+		load, from := c.load, c.cg
+		c.mu.Unlock()
+		to, ok := rt.lookupGroup(id)
+		if !ok {
+			to = held[id]
+		}
+		var src, dst *NodeImpact
+		if from != nil {
+			src = impact(from.Node())
+			src.LoadBefore += load
+		}
+		if to != nil {
+			dst = impact(to.Node())
+			dst.LoadAfter += load
+		}
+		// Cells without the new owner stay where they are, such partitions are invalid.
+		if src == dst || dst == nil || load == 0 {
+			continue
+		}
+		res.CellsMoved++
+		res.BytesMoved += load
+		dst.BytesIn += load
+		if src != nil {
+			src.BytesOut += load
+		}
+	}
+
+	var loadBefore, loadAfter uint64
+	var powerBefore, powerAfter float64
+	for _, cg := range s.cgs {
+		powerBefore += cg.Node().Power().Get()
+	}
+	for _, cg := range cgs {
+		powerAfter += cg.Node().Power().Get()
+	}
+	for _, ni := range nodes {
+		loadBefore += ni.LoadBefore
+		loadAfter += ni.LoadAfter
+	}
+	for _, ni := range nodes {
+		p := ni.Node.Power().Get()
+		if loadBefore > 0 && p > 0 {
+			ni.RatioBefore = float64(ni.LoadBefore) / float64(loadBefore) / (p / powerBefore)
+		}
+		if loadAfter > 0 && p > 0 {
+			ni.RatioAfter = float64(ni.LoadAfter) / float64(loadAfter) / (p / powerAfter)
+		}
+		ni.OverCapacity = float64(ni.LoadAfter) > ni.Node.Capacity().Get()
+		res.Nodes = append(res.Nodes, *ni)
+	}
+	sort.Slice(res.Nodes, func(i, j int) bool {
+		return res.Nodes[i].Node.ID() < res.Nodes[j].Node.ID()
+	})
+	return res
+}
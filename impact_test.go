@@ -0,0 +1,82 @@
+package balancer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+func TestSpace_DryRun(t *testing.T) {
+	s := newTestSpace(t, curve.Hilbert, 2, 4, "n1", "n2")
+	for x := uint64(0); x < 16; x += 4 {
+		for y := uint64(0); y < 16; y += 4 {
+			if _, err := s.AddData(testItem{"d", 10, []uint64{x, y}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// n1 holds [0, 128) and n2 holds [128, 256), capacity of nodes is 100.
+	cgs, err := shiftOptimizer(s.Clone())
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := s.RoutingState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	impact := s.DryRun(cgs)
+	after, err := s.RoutingState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(state, after) {
+		t.Fatal("DryRun changed the space")
+	}
+	if impact.Invalid != nil {
+		t.Fatalf("unexpected validation error: %v", impact.Invalid)
+	}
+	if len(impact.Nodes) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(impact.Nodes))
+	}
+	n1, n2 := impact.Nodes[0], impact.Nodes[1]
+	if n1.Node.ID() != "n1" || n2.Node.ID() != "n2" {
+		t.Fatal("nodes must be sorted by ID")
+	}
+	if !reflect.DeepEqual(n1.Before, []Range{{0, 128, 128}}) || !reflect.DeepEqual(n1.After, []Range{{0, 64, 64}}) {
+		t.Fatalf("unexpected ranges of n1: %v -> %v", n1.Before, n1.After)
+	}
+	if !reflect.DeepEqual(n2.After, []Range{{64, 256, 192}}) {
+		t.Fatalf("unexpected ranges of n2: %v", n2.After)
+	}
+	if n1.BytesOut != impact.BytesMoved || n2.BytesIn != impact.BytesMoved || n1.BytesIn != 0 || n2.BytesOut != 0 {
+		t.Fatalf("unexpected bytes moved: %+v", impact)
+	}
+	if impact.CellsMoved != 4 || impact.BytesMoved != 40 {
+		t.Fatalf("unexpected number of moved cells %d", impact.CellsMoved)
+	}
+	if n1.LoadBefore-n1.LoadAfter != impact.BytesMoved || n1.LoadBefore+n2.LoadBefore != 160 {
+		t.Fatalf("unexpected loads: %+v %+v", n1, n2)
+	}
+	if n2.RatioAfter <= n2.RatioBefore {
+		t.Fatalf("ratio of n2 must grow: %v -> %v", n2.RatioBefore, n2.RatioAfter)
+	}
+	if !n2.OverCapacity || n1.OverCapacity || !reflect.DeepEqual(impact.OverCapacity(), []string{"n2"}) {
+		t.Fatalf("expected n2 to exceed capacity: %+v", impact)
+	}
+
+	if err := s.RemoveNode("n2"); err != nil {
+		t.Fatal(err)
+	}
+	impact = s.DryRun(s.CellGroups())
+	if impact.Invalid == nil {
+		t.Fatal("expected validation error for the gap left by removed node")
+	}
+	n2 = impact.Nodes[1]
+	if n2.Node.ID() != "n2" || n2.LoadBefore != 80 || n2.LoadAfter != 0 || n2.After != nil {
+		t.Fatalf("unexpected impact on removed node: %+v", n2)
+	}
+	if impact.CellsMoved != 0 || n2.BytesOut != 0 {
+		t.Fatal("cells without new owner must not be counted as moved")
+	}
+}
@@ -0,0 +1,381 @@
+package balancer
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Segment is a range of cells held by the node. During the migration a node may hold
+// several segments.
+type Segment struct {
+	Range Range
+	Node  Node
+}
+
+// Move is a range of cells which changes its node.
+//
+// Bytes - load of the cells at the start of the migration.
+type Move struct {
+	Range Range
+	From  Node
+	To    Node
+	Bytes uint64
+}
+
+// Migration is a staged transition of the space from the current partition to the new one.
+// The transition is split into steps, each step moves at most the configured number of bytes
+// unless a single cell is larger. Routing of the space follows the last completed step, so
+// the caller moves data of the next step and then calls Advance to switch routing to it.
+//
+// Cells of the next step are in flight: reads go to the old node and writes go to both
+// nodes. CompleteCell switches a single cell to the new node before the whole step
+// is completed.
+type Migration struct {
+	s *Space
+	// initial are cell groups of the space before the migration.
+	initial []*CellGroup
+	target  []*CellGroup
+	pieces  []piece
+	steps   int
+	step    int
+	done    bool
+	aborted bool
+	// completed are cells of the next step which are already moved to their groups.
+	completed map[uint64]*CellGroup
+}
+
+// piece is a range of cells with the same owners before and after the migration which
+// moves in one step. Unchanged pieces have step -1.
+type piece struct {
+	r     Range
+	from  *CellGroup
+	to    *CellGroup
+	bytes uint64
+	step  int
+}
+
+// StartMigration starts staged transition to cell groups returned by Optimize. Steps move
+// at most maxBytes bytes each, 0 means no limit. If the groups do not change placement of
+// any cell, the migration is completed immediately. Ranges which are not held by any node
+// at the start are assigned to their new owners right away.
+func (s *Space) StartMigration(cgs []*CellGroup, maxBytes uint64) (*Migration, error) {
+	if err := s.ValidateGroups(cgs); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.migration != nil {
+		return nil, errors.Wrap(ErrMigrationInProgress, "unable to start migration")
+	}
+	m := &Migration{
+		s:         s,
+		initial:   s.cgs,
+		target:    cgs,
+		completed: map[uint64]*CellGroup{},
+	}
+	// Nodes which are not present in the new partition keep their groups until their
+	// cells are moved out.
+	groups := make(map[string]*CellGroup, len(cgs))
+	union := make([]*CellGroup, 0, len(cgs))
+	for _, cg := range cgs {
+		groups[cg.ID()] = cg
+		union = append(union, cg)
+	}
+	for _, cg := range s.cgs {
+		if _, ok := groups[cg.ID()]; !ok {
+			groups[cg.ID()] = cg
+			union = append(union, cg)
+		}
+	}
+	ids, loads := s.sortedCells()
+	m.split(s.routingTable(), newRoutingTable(cgs), groups, ids, loads, maxBytes)
+	s.migration = m
+	s.cgs = union
+	if m.steps == 0 {
+		m.finish()
+		return m, nil
+	}
+	s.updateRoutes()
+	s.bindCells()
+	return m, nil
+}
+
+// Migration returns the migration in progress or nil.
+func (s *Space) Migration() *Migration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.migration
+}
+
+// sortedCells returns IDs and loads of cells sorted by ID.
+func (s *Space) sortedCells() ([]uint64, []uint64) {
+	ids := make([]uint64, 0, len(s.cells))
+	for id := range s.cells {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	loads := make([]uint64, len(ids))
+	for iter, id := range ids {
+		loads[iter] = s.cells[id].Load()
+	}
+	return ids, loads
+}
+
+// split overlays old and new routing tables and splits changed ranges into steps.
+func (m *Migration) split(cur, next *routingTable, groups map[string]*CellGroup, ids, loads []uint64, maxBytes uint64) {
+	bounds := make([]uint64, 0, 2*(len(cur.mins)+len(next.mins)))
+	bounds = append(bounds, cur.mins...)
+	bounds = append(bounds, cur.maxs...)
+	bounds = append(bounds, next.mins...)
+	bounds = append(bounds, next.maxs...)
+	sort.Slice(bounds, func(i, j int) bool {
+		return bounds[i] < bounds[j]
+	})
+	var step int
+	var stepBytes uint64
+	var ci int
+	for iter := 0; iter+1 < len(bounds); iter++ {
+		min, max := bounds[iter], bounds[iter+1]
+		if min == max {
+			continue
+		}
+		to, ok := next.lookupGroup(min)
+		if !ok {
+			continue
+		}
+		to = groups[to.ID()]
+		from := to
+		if cg, ok := cur.lookupGroup(min); ok {
+			from = groups[cg.ID()]
+		}
+		if from == to {
+			m.pieces = append(m.pieces, piece{r: newRange(min, max), from: from, to: to, step: -1})
+			continue
+		}
+		for ci < len(ids) && ids[ci] < min {
+			ci++
+		}
+		start := min
+		var bytes uint64
+		for ; ci < len(ids) && ids[ci] < max; ci++ {
+			if maxBytes > 0 && stepBytes+bytes > 0 && stepBytes+bytes+loads[ci] > maxBytes {
+				if ids[ci] > start {
+					m.pieces = append(m.pieces, piece{r: newRange(start, ids[ci]), from: from, to: to, bytes: bytes, step: step})
+				}
+				step++
+				stepBytes, bytes, start = 0, 0, ids[ci]
+			}
+			bytes += loads[ci]
+		}
+		m.pieces = append(m.pieces, piece{r: newRange(start, max), from: from, to: to, bytes: bytes, step: step})
+		stepBytes += bytes
+		m.steps = step + 1
+	}
+}
+
+func newRange(min, max uint64) Range {
+	return Range{
+		Min: min,
+		Max: max,
+		Len: max - min,
+	}
+}
+
+// segments returns routing segments after the current step including completed cells
+// of the next step. It must be called under the lock of the space.
+func (m *Migration) segments() []segment {
+	segs := m.partition(m.step)
+	if len(m.completed) == 0 {
+		return segs
+	}
+	ids := make([]uint64, 0, len(m.completed))
+	for id := range m.completed {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	res := make([]segment, 0, len(segs)+2*len(ids))
+	for _, seg := range segs {
+		min := seg.r.Min
+		for len(ids) > 0 && ids[0] < seg.r.Max {
+			res = appendSegment(res, segment{r: newRange(min, ids[0]), cg: seg.cg})
+			res = appendSegment(res, segment{r: newRange(ids[0], ids[0]+1), cg: m.completed[ids[0]]})
+			min = ids[0] + 1
+			ids = ids[1:]
+		}
+		res = appendSegment(res, segment{r: newRange(min, seg.r.Max), cg: seg.cg})
+	}
+	return res
+}
+
+// pending returns ranges of cells of the next step with their new groups. It must be
+// called under the lock of the space.
+func (m *Migration) pending() []segment {
+	var res []segment
+	for _, p := range m.pieces {
+		if p.step == m.step {
+			res = appendSegment(res, segment{r: p.r, cg: p.to})
+		}
+	}
+	return res
+}
+
+// partition returns segments of the partition after the given number of steps.
+func (m *Migration) partition(steps int) []segment {
+	var res []segment
+	for _, p := range m.pieces {
+		cg := p.from
+		if p.step >= 0 && p.step < steps {
+			cg = p.to
+		}
+		res = appendSegment(res, segment{r: p.r, cg: cg})
+	}
+	return res
+}
+
+// appendSegment appends the segment merging it with the last one if they are adjacent
+// and belong to the same group. Empty segments are skipped.
+func appendSegment(segs []segment, seg segment) []segment {
+	if seg.r.Len == 0 {
+		return segs
+	}
+	if l := len(segs) - 1; l >= 0 && segs[l].cg == seg.cg && segs[l].r.Max == seg.r.Min {
+		segs[l].r = newRange(segs[l].r.Min, seg.r.Max)
+		return segs
+	}
+	return append(segs, seg)
+}
+
+// CompleteCell marks the cell of the next step as moved, reads and writes of the cell go
+// to its new node.
+func (m *Migration) CompleteCell(cID uint64) error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	if m.done || m.aborted {
+		return errors.New("migration is not in progress")
+	}
+	for _, p := range m.pieces {
+		if p.step == m.step && cID >= p.r.Min && cID < p.r.Max {
+			if _, ok := m.completed[cID]; ok {
+				return nil
+			}
+			m.completed[cID] = p.to
+			m.s.updateRoutes()
+			m.s.bindCell(cID)
+			return nil
+		}
+	}
+	return errors.Errorf("cell(%d) is not moved by step %d", cID, m.step)
+}
+
+// Steps returns the number of steps of the migration.
+func (m *Migration) Steps() int {
+	return m.steps
+}
+
+// Step returns the number of completed steps.
+func (m *Migration) Step() int {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	return m.step
+}
+
+// Done reports whether all steps are completed and the new partition is applied.
+func (m *Migration) Done() bool {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	return m.done
+}
+
+// Aborted reports whether the migration was aborted or discarded by SetGroups.
+func (m *Migration) Aborted() bool {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	return m.aborted
+}
+
+// Moves returns ranges of cells moved by the step, steps are numbered from 0.
+func (m *Migration) Moves(step int) []Move {
+	var res []Move
+	for _, p := range m.pieces {
+		if p.step != step {
+			continue
+		}
+		if l := len(res) - 1; l >= 0 && res[l].Range.Max == p.r.Min &&
+			res[l].From.ID() == p.from.ID() && res[l].To.ID() == p.to.ID() {
+			res[l].Range = newRange(res[l].Range.Min, p.r.Max)
+			res[l].Bytes += p.bytes
+			continue
+		}
+		res = append(res, Move{
+			Range: p.r,
+			From:  p.from.Node(),
+			To:    p.to.Node(),
+			Bytes: p.bytes,
+		})
+	}
+	return res
+}
+
+// Partition returns the partition of the space after the given number of steps,
+// Partition(0) is the partition before the migration and Partition(Steps()) is the new one.
+func (m *Migration) Partition(steps int) []Segment {
+	segs := m.partition(steps)
+	res := make([]Segment, len(segs))
+	for iter := range segs {
+		res[iter] = Segment{
+			Range: segs[iter].r,
+			Node:  segs[iter].cg.Node(),
+		}
+	}
+	return res
+}
+
+// Advance completes the next step and switches routing of its cells to new nodes. Data
+// of the step must be moved before. After the last step the new partition is applied.
+func (m *Migration) Advance() error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	if m.done || m.aborted {
+		return errors.New("migration is not in progress")
+	}
+	m.step++
+	m.completed = map[uint64]*CellGroup{}
+	if m.step == m.steps {
+		m.finish()
+		return nil
+	}
+	m.s.updateRoutes()
+	m.s.bindCells()
+	return nil
+}
+
+// Abort stops the migration and restores the partition which was used before it.
+// Data moved by completed steps must be moved back by the caller, Moves returns it.
+func (m *Migration) Abort() error {
+	m.s.mu.Lock()
+	defer m.s.mu.Unlock()
+	if m.done || m.aborted {
+		return errors.New("migration is not in progress")
+	}
+	m.aborted = true
+	m.s.migration = nil
+	m.s.cgs = m.initial
+	m.s.updateRoutes()
+	m.s.bindCells()
+	return nil
+}
+
+// finish applies the new partition. It must be called under the lock of the space.
+func (m *Migration) finish() {
+	m.done = true
+	m.step = m.steps
+	m.s.migration = nil
+	m.s.cgs = m.target
+	m.s.updateRoutes()
+	m.s.bindCells()
+}
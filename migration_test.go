@@ -0,0 +1,331 @@
+package balancer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+func newMigrationSpace(t *testing.T) *Space {
+	s := newTestSpace(t, curve.Hilbert, 2, 4, "n1", "n2")
+	for x := uint64(0); x < 16; x += 4 {
+		for y := uint64(0); y < 16; y += 4 {
+			if _, err := s.AddData(testItem{"d", 10, []uint64{x, y}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return s
+}
+
+func checkBinding(t *testing.T, s *Space) {
+	t.Helper()
+	var load uint64
+	for _, cg := range s.CellGroups() {
+		load += cg.TotalLoad()
+		for id, c := range cg.Cells() {
+			n, ok := s.routingTable().lookup(id)
+			if !ok || n.ID() != cg.ID() || c.cg != cg {
+				t.Fatalf("cell %d is bound to %s, but routed to %v", id, cg.ID(), n)
+			}
+		}
+	}
+	if load != s.TotalLoad() {
+		t.Fatalf("load of groups %d does not match load of the space %d", load, s.TotalLoad())
+	}
+}
+
+func TestSpace_StartMigration(t *testing.T) {
+	s := newMigrationSpace(t)
+	initial, err := s.RoutingState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// [64, 128) moves from n1 to n2, it holds 4 cells of 10 bytes.
+	cgs, err := shiftOptimizer(s.Clone())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := s.StartMigration(cgs, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Steps() != 4 || m.Step() != 0 || m.Done() {
+		t.Fatalf("expected 4 pending steps, got %d of %d", m.Step(), m.Steps())
+	}
+	if _, err := s.StartMigration(cgs, 15); err == nil {
+		t.Fatal("expected error for the second migration")
+	}
+	if err := s.RemoveNode("n1"); err == nil {
+		t.Fatal("expected error for removing node during migration")
+	}
+	state, err := s.RoutingState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(state.Ranges, initial.Ranges) || !reflect.DeepEqual(state.Nodes, initial.Nodes) {
+		t.Fatal("routing changed before the first step")
+	}
+	if p := m.Partition(0); len(p) != 2 || p[0].Range != (Range{0, 128, 128}) || p[0].Node.ID() != "n1" {
+		t.Fatalf("unexpected initial partition %v", p)
+	}
+	if p := m.Partition(m.Steps()); len(p) != 2 || p[0].Range != (Range{0, 64, 64}) || p[1].Node.ID() != "n2" {
+		t.Fatalf("unexpected target partition %v", p)
+	}
+
+	var moved, movedCells uint64
+	for step := 0; step < m.Steps(); step++ {
+		moves := m.Moves(step)
+		if len(moves) != 1 || moves[0].Bytes != 10 || moves[0].From.ID() != "n1" || moves[0].To.ID() != "n2" {
+			t.Fatalf("unexpected moves of step %d: %v", step, moves)
+		}
+		moved += moves[0].Bytes
+		epoch := s.Epoch()
+		if err := m.Advance(); err != nil {
+			t.Fatal(err)
+		}
+		if s.Epoch() == epoch {
+			t.Fatal("Advance did not change the epoch")
+		}
+		checkBinding(t, s)
+		state, err := s.RoutingState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// n1 holds cells of the space which are not moved yet.
+		var n1 uint64
+		for iter := range state.Ranges {
+			if state.Nodes[iter] == "n1" {
+				n1 += state.Ranges[iter].Len
+			}
+		}
+		movedCells += moves[0].Range.Len
+		if n1 != 128-movedCells {
+			t.Fatalf("n1 holds %d cells after step %d, want %d", n1, step, 128-movedCells)
+		}
+	}
+	if moved != 40 {
+		t.Fatalf("expected 40 bytes moved, got %d", moved)
+	}
+	if !m.Done() || s.Migration() != nil {
+		t.Fatal("migration must be completed")
+	}
+	if err := m.Advance(); err == nil {
+		t.Fatal("expected error for advancing completed migration")
+	}
+	final, err := s.RoutingState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(final.Ranges, []Range{{0, 64, 64}, {64, 256, 192}}) {
+		t.Fatalf("unexpected final partition %v", final.Ranges)
+	}
+	checkBinding(t, s)
+}
+
+func TestMigration_Abort(t *testing.T) {
+	s := newMigrationSpace(t)
+	initial, err := s.RoutingState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cgs, err := shiftOptimizer(s.Clone())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := s.StartMigration(cgs, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Steps() != 1 {
+		t.Fatalf("migration without limit must have 1 step, got %d", m.Steps())
+	}
+	if err := s.AddNode(testNode{id: "n3", power: 1}); err == nil {
+		t.Fatal("expected error for AddNode during migration")
+	}
+	if err := s.RemoveNode("n2"); err == nil {
+		t.Fatal("expected error for RemoveNode during migration")
+	}
+	if err := m.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Aborted() || s.Migration() != nil {
+		t.Fatal("migration must be aborted")
+	}
+	state, err := s.RoutingState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(state.Ranges, initial.Ranges) || !reflect.DeepEqual(state.Nodes, initial.Nodes) {
+		t.Fatal("Abort did not restore routing")
+	}
+	checkBinding(t, s)
+
+	m, err = s.StartMigration(s.Clone().CellGroups(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Done() || m.Steps() != 0 {
+		t.Fatal("migration without changes must be completed immediately")
+	}
+}
+
+func TestBalancer_StageApply(t *testing.T) {
+	b := &Balancer{
+		space: newMigrationSpace(t),
+		of:    shiftOptimizer,
+	}
+	cgs, err := b.Optimize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := b.StageApply(cgs, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Migration() != m || m.Steps() != 2 {
+		t.Fatalf("expected migration with 2 steps, got %d", m.Steps())
+	}
+	if err := b.Apply(cgs); err == nil {
+		t.Fatal("expected error for Apply during migration")
+	}
+	if err := b.AddNode(testNode{id: "n3", power: 1}, false); err == nil {
+		t.Fatal("expected error for AddNode during migration")
+	}
+	for !m.Done() {
+		if err := m.Advance(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.AddNode(testNode{id: "n3", power: 1}, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func nodeIDs(ns []Node) []string {
+	res := make([]string, len(ns))
+	for iter := range ns {
+		res[iter] = ns[iter].ID()
+	}
+	return res
+}
+
+func TestMigration_CompleteCell(t *testing.T) {
+	s := newMigrationSpace(t)
+	cgs, err := shiftOptimizer(s.Clone())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := s.StartMigration(cgs, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Routes of populated cells, the cells of the first and the second steps and the cell
+	// which is not moved.
+	moving := map[int]Route{}
+	var still Route
+	for x := uint64(0); x < 16; x += 4 {
+		for y := uint64(0); y < 16; y += 4 {
+			r, err := s.LocateData(testItem{"d", 0, []uint64{x, y}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for step := 0; step < 2; step++ {
+				if mv := m.Moves(step)[0]; r.CellID >= mv.Range.Min && r.CellID < mv.Range.Max {
+					moving[step] = r
+				}
+			}
+			if r.CellID >= 128 {
+				still = r
+			}
+		}
+	}
+	first, second := moving[0], moving[1]
+	if first.Node.ID() != "n1" || !reflect.DeepEqual(nodeIDs(first.Write), []string{"n1", "n2"}) {
+		t.Fatalf("cell in flight must be read from n1 and written to both nodes: %v %v", first.Node, nodeIDs(first.Write))
+	}
+	if !reflect.DeepEqual(nodeIDs(second.Write), []string{"n1"}) {
+		t.Fatalf("cell of the next step must be written to n1 only: %v", nodeIDs(second.Write))
+	}
+	if !reflect.DeepEqual(nodeIDs(still.Write), []string{"n2"}) {
+		t.Fatalf("cell which is not moved must be written to its node only: %v", nodeIDs(still.Write))
+	}
+	rt := s.routingTable()
+	for _, cID := range []uint64{first.CellID, still.CellID} {
+		i, _ := rt.search(cID)
+		if allocs := testing.AllocsPerRun(100, func() { rt.writeNodes(i, cID) }); allocs != 0 {
+			t.Errorf("writeNodes() allocations = %v, want 0", allocs)
+		}
+	}
+
+	if err := m.CompleteCell(second.CellID); err == nil {
+		t.Fatal("expected error for completing cell of the next step")
+	}
+	if err := m.CompleteCell(first.CellID); err != nil {
+		t.Fatal(err)
+	}
+	r, err := s.LocateData(testItem{"d", 0, s.decode(t, first.CellID)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Node.ID() != "n2" || !reflect.DeepEqual(nodeIDs(r.Write), []string{"n2"}) {
+		t.Fatalf("completed cell must be routed to n2: %v %v", r.Node, nodeIDs(r.Write))
+	}
+	checkBinding(t, s)
+
+	if err := m.Advance(); err != nil {
+		t.Fatal(err)
+	}
+	r, err = s.LocateData(testItem{"d", 0, s.decode(t, second.CellID)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Node.ID() != "n1" || !reflect.DeepEqual(nodeIDs(r.Write), []string{"n1", "n2"}) {
+		t.Fatalf("cell of the second step must be in flight: %v %v", r.Node, nodeIDs(r.Write))
+	}
+	checkBinding(t, s)
+}
+
+func (s *Space) decode(t *testing.T, cID uint64) []uint64 {
+	coords, err := s.sfc.Decode(cID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return coords
+}
+
+func TestSpace_AddDataRouteMigration(t *testing.T) {
+	s := newMigrationSpace(t)
+	cgs, err := shiftOptimizer(s.Clone())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := s.StartMigration(cgs, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mv := m.Moves(0)[0]
+	moving := testItem{"moving", 5, s.decode(t, mv.Range.Min)}
+	r, err := s.AddDataRoute(moving)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Node.ID() != "n1" || !reflect.DeepEqual(nodeIDs(r.Write), []string{"n1", "n2"}) {
+		t.Fatalf("item in flight must be counted on n1 and written to both nodes: %v %v", r.Node, nodeIDs(r.Write))
+	}
+	still := testItem{"still", 5, s.decode(t, 200)}
+	rs, errs := s.AddDataBatchRoutes([]DataItem{moving, still})
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reflect.DeepEqual(rs[0], r) {
+		t.Errorf("AddDataBatchRoutes() route = %+v, want %+v", rs[0], r)
+	}
+	if !reflect.DeepEqual(nodeIDs(rs[1].Write), []string{"n2"}) {
+		t.Errorf("item which is not moved must be written to its node only: %v", nodeIDs(rs[1].Write))
+	}
+	checkBinding(t, s)
+}
@@ -14,11 +14,13 @@ func NewMockSpace(cgs []*CellGroup, sfc curve.Curve) *Space {
 			load += c.load
 		}
 	}
-	return &Space{
+	s := &Space{
 		cells: cs,
 		cgs:   cgs,
 		load:  load,
 		sfc:   sfc,
 		tf:    transform.SpaceTransform,
 	}
+	s.updateRoutes()
+	return s
 }
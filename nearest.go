@@ -0,0 +1,177 @@
+package balancer
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+type cellDistance struct {
+	c    *cell
+	dist float64
+}
+
+// LocateNearest returns nodes holding k populated cells which are the closest to the data
+// item in the coordinate space of the curve. Nodes are ordered by the distance to their
+// closest cell.
+//
+// The search goes outward from the cell of the data item layer by layer, and falls back to
+// the scan of all populated cells when the searched area becomes larger than their number.
+func (s *Space) LocateNearest(d DataItem, k int) ([]Node, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if k <= 0 {
+		return nil, errors.New("number of cells must be greater than 0")
+	}
+	if s.tf == nil {
+		return nil, errors.New("transform function is not set")
+	}
+	coords, err := s.tf(d.Values(), s.sfc)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(coords)) != s.sfc.Dimensions() {
+		return nil, errors.Errorf("number of coordinates(%d) is not equal to dimensions(%d)", len(coords), s.sfc.Dimensions())
+	}
+	var cds []cellDistance
+	if cds, err = s.searchOutward(coords, k); err != nil {
+		return nil, err
+	}
+	if cds == nil {
+		if cds, err = s.scanCells(coords); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(cds, func(i, j int) bool {
+		if cds[i].dist == cds[j].dist {
+			return cds[i].c.id < cds[j].c.id
+		}
+		return cds[i].dist < cds[j].dist
+	})
+	if len(cds) > k {
+		cds = cds[:k]
+	}
+	seen := map[string]bool{}
+	res := make([]Node, 0, len(cds))
+	for _, cd := range cds {
+		n := cd.c.cg.Node()
+		if seen[n.ID()] {
+			continue
+		}
+		seen[n.ID()] = true
+		res = append(res, n)
+	}
+	return res, nil
+}
+
+// searchOutward checks cells in layers of growing Chebyshev radius around the center.
+// It returns nil if the number of checked cells exceeds the number of populated cells.
+func (s *Space) searchOutward(center []uint64, k int) ([]cellDistance, error) {
+	dimSize := s.sfc.DimensionSize()
+	budget := len(s.cells)
+	var res []cellDistance
+	buf := make([]uint64, len(center))
+	for r := uint64(0); r <= dimSize; r++ {
+		if len(res) >= k {
+			sort.Slice(res, func(i, j int) bool { return res[i].dist < res[j].dist })
+			if res[k-1].dist < float64(r)*float64(r) {
+				return res, nil
+			}
+		}
+		lo := make([]uint64, len(center))
+		hi := make([]uint64, len(center))
+		volume := 1
+		for iter := range center {
+			lo[iter], hi[iter] = 0, dimSize
+			if center[iter] > r {
+				lo[iter] = center[iter] - r
+			}
+			if dimSize-center[iter] > r {
+				hi[iter] = center[iter] + r
+			}
+			volume *= int(hi[iter] - lo[iter] + 1)
+			if volume > budget {
+				return nil, nil
+			}
+		}
+		point := append([]uint64(nil), lo...)
+		for {
+			if chebyshev(point, center) == r {
+				copy(buf, point)
+				cID, err := s.sfc.Encode(buf)
+				if err != nil {
+					return nil, errors.Wrap(err, "cell encoding error")
+				}
+				if c, ok := s.cells[cID]; ok && c.Load() > 0 {
+					res = append(res, cellDistance{c: c, dist: euclidean(point, center)})
+				}
+			}
+			if !nextPoint(point, lo, hi) {
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// scanCells decodes all populated cells and computes their distances to the center.
+func (s *Space) scanCells(center []uint64) ([]cellDistance, error) {
+	res := make([]cellDistance, 0, len(s.cells))
+	buf := make([]uint64, len(center))
+	for id, c := range s.cells {
+		if c.Load() == 0 {
+			continue
+		}
+		for iter := range buf {
+			buf[iter] = 0
+		}
+		coords, err := s.sfc.DecodeWithBuffer(buf, id)
+		if err != nil {
+			return nil, errors.Wrap(err, "cell decoding error")
+		}
+		res = append(res, cellDistance{c: c, dist: euclidean(coords, center)})
+	}
+	return res, nil
+}
+
+// nextPoint moves point to the next position inside the box [lo, hi].
+// It returns false when all positions were visited.
+func nextPoint(point, lo, hi []uint64) bool {
+	for iter := range point {
+		if point[iter] < hi[iter] {
+			point[iter]++
+			return true
+		}
+		point[iter] = lo[iter]
+	}
+	return false
+}
+
+func chebyshev(a, b []uint64) uint64 {
+	var res uint64
+	for iter := range a {
+		if d := absDiff(a[iter], b[iter]); d > res {
+			res = d
+		}
+	}
+	return res
+}
+
+// euclidean returns squared Euclidean distance between two points. It is computed in
+// float64, because squares of coordinates of curves with more than 32 bits per dimension
+// overflow uint64.
+func euclidean(a, b []uint64) float64 {
+	var res float64
+	for iter := range a {
+		d := float64(absDiff(a[iter], b[iter]))
+		res += d * d
+	}
+	return res
+}
+
+func absDiff(a, b uint64) uint64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -0,0 +1,168 @@
+package balancer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+type testPower float64
+
+func (p testPower) Get() float64 {
+	return float64(p)
+}
+
+type testNode struct {
+	id       string
+	power    float64
+	capacity float64
+}
+
+func (n testNode) ID() string {
+	return n.id
+}
+
+func (n testNode) Power() Power {
+	return testPower(n.power)
+}
+
+func (n testNode) Capacity() Capacity {
+	return testPower(n.capacity)
+}
+
+type testItem struct {
+	id     string
+	size   uint64
+	coords []uint64
+}
+
+func (d testItem) ID() string {
+	return d.id
+}
+
+func (d testItem) Size() uint64 {
+	return d.size
+}
+
+func (d testItem) Values() []interface{} {
+	res := make([]interface{}, len(d.coords))
+	for iter := range d.coords {
+		res[iter] = d.coords[iter]
+	}
+	return res
+}
+
+func coordsTransform(values []interface{}, sfc curve.Curve) ([]uint64, error) {
+	res := make([]uint64, len(values))
+	for iter := range values {
+		res[iter] = values[iter].(uint64)
+	}
+	return res, nil
+}
+
+func newTestSpace(t testing.TB, cType curve.CurveType, dims, bits uint64, nodes ...string) *Space {
+	sfc, err := curve.NewCurve(cType, dims, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns := make([]Node, len(nodes))
+	for iter := range nodes {
+		ns[iter] = testNode{id: nodes[iter], power: 1, capacity: 100}
+	}
+	s, err := NewSpace(sfc, coordsTransform, ns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSpace_LocateNearest(t *testing.T) {
+	s := newTestSpace(t, curve.Hilbert, 2, 4, "n1", "n2", "n3", "n4")
+	items := []testItem{
+		{"d1", 1, []uint64{0, 0}},
+		{"d2", 1, []uint64{15, 1}},
+		{"d3", 1, []uint64{15, 15}},
+		{"d4", 1, []uint64{0, 15}},
+	}
+	owners := map[string]string{}
+	for _, d := range items {
+		n, err := s.AddData(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		owners[d.id] = n.ID()
+	}
+	tests := []struct {
+		name  string
+		query []uint64
+		k     int
+		want  []string
+	}{
+		{
+			name:  "single closest",
+			query: []uint64{14, 13},
+			k:     1,
+			want:  []string{owners["d3"]},
+		},
+		{
+			name:  "two closest",
+			query: []uint64{1, 6},
+			k:     2,
+			want:  []string{owners["d1"], owners["d4"]},
+		},
+		{
+			name:  "all cells",
+			query: []uint64{14, 2},
+			k:     10,
+			want:  []string{owners["d2"], owners["d3"], owners["d1"], owners["d4"]},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ns, err := s.LocateNearest(testItem{coords: tt.query}, tt.k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, len(ns))
+			for iter := range ns {
+				got[iter] = ns[iter].ID()
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LocateNearest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpace_LocateNearestWide(t *testing.T) {
+	s := newTestSpace(t, curve.Hilbert, 1, 63, "n1", "n2")
+	items := []testItem{
+		{"far", 1, []uint64{0}},
+		{"near", 1, []uint64{1<<62 + 7}},
+	}
+	owners := map[string]string{}
+	for _, d := range items {
+		n, err := s.AddData(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		owners[d.id] = n.ID()
+	}
+	if owners["far"] == owners["near"] {
+		t.Fatalf("cells must be held by different nodes: %v", owners)
+	}
+	// Squared distance to the far cell overflows uint64 and wraps to 100, which is less
+	// than 289 of the near cell.
+	ns, err := s.LocateNearest(testItem{coords: []uint64{1<<62 - 10}}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, len(ns))
+	for iter := range ns {
+		got[iter] = ns[iter].ID()
+	}
+	if want := []string{owners["near"], owners["far"]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("LocateNearest() = %v, want %v", got, want)
+	}
+}
@@ -6,3 +6,30 @@ type Node interface {
 	Power() Power
 	Capacity() Capacity
 }
+
+type node struct {
+	id       string
+	power    PowerValue
+	capacity CapacityValue
+}
+
+// NewNode creates node with constant power and capacity.
+func NewNode(id string, power, capacity float64) Node {
+	return node{
+		id:       id,
+		power:    PowerValue(power),
+		capacity: CapacityValue(capacity),
+	}
+}
+
+func (n node) ID() string {
+	return n.id
+}
+
+func (n node) Power() Power {
+	return n.power
+}
+
+func (n node) Capacity() Capacity {
+	return n.capacity
+}
@@ -0,0 +1,191 @@
+package optimizer
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/visheratin/balancer"
+	"github.com/visheratin/balancer/curve"
+	"github.com/visheratin/balancer/transform"
+)
+
+type testValue float64
+
+func (v testValue) Get() float64 {
+	return float64(v)
+}
+
+type testNode struct {
+	id       string
+	power    float64
+	capacity float64
+}
+
+func (n testNode) ID() string {
+	return n.id
+}
+
+func (n testNode) Power() balancer.Power {
+	return testValue(n.power)
+}
+
+func (n testNode) Capacity() balancer.Capacity {
+	return testValue(n.capacity)
+}
+
+type testItem struct {
+	id       string
+	size     uint64
+	lat, lon float64
+}
+
+func (d testItem) ID() string {
+	return d.id
+}
+
+func (d testItem) Size() uint64 {
+	return d.size
+}
+
+func (d testItem) Values() []interface{} {
+	return []interface{}{d.lat, d.lon}
+}
+
+func newTestSpace(t *testing.T, items int) *balancer.Space {
+	sfc, err := curve.NewCurve(curve.Hilbert, 2, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := []balancer.Node{
+		testNode{id: "n1", power: 1, capacity: 1e6},
+		testNode{id: "n2", power: 2, capacity: 1e6},
+		testNode{id: "n3", power: 3, capacity: 1e6},
+	}
+	s, err := balancer.NewSpace(sfc, transform.SpaceTransform, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for iter := 0; iter < items; iter++ {
+		d := testItem{
+			id:   fmt.Sprintf("d%d", iter),
+			size: uint64(rnd.Intn(100) + 1),
+			lat:  rnd.NormFloat64() * 20,
+			lon:  rnd.NormFloat64() * 40,
+		}
+		if _, err := s.AddData(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestOptimizersPartition(t *testing.T) {
+	tests := []struct {
+		name string
+		of   balancer.OptimizerFunc
+	}{
+		{"PowerOptimizer", PowerOptimizer},
+		{"RangeOptimizer", RangeOptimizer},
+		{"PowerRangeOptimizer", PowerRangeOptimizer},
+		{"PowerOptimizerPerms", PowerOptimizerPerms},
+		{"PowerOptimizerGreedy", PowerOptimizerGreedy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSpace(t, 1000)
+			cgs, err := tt.of(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(cgs) != 3 {
+				t.Errorf("%s() returned %d groups, want 3", tt.name, len(cgs))
+			}
+			if err := s.ValidateGroups(cgs); err != nil {
+				t.Errorf("%s() returned invalid partition: %v", tt.name, err)
+			}
+		})
+	}
+}
+
+func TestPowerOptimizerPerms(t *testing.T) {
+	s := newTestSpace(t, 1000)
+	if err := s.AddNode(testNode{id: "n4", power: 2, capacity: 1e6}); err != nil {
+		t.Fatal(err)
+	}
+	cgs, err := PowerOptimizerPerms(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ValidateGroups(cgs); err != nil {
+		t.Fatalf("PowerOptimizerPerms() returned invalid partition: %v", err)
+	}
+	last := cgs[len(cgs)-1]
+	if last.ID() != "n4" || last.TotalLoad() == 0 {
+		t.Fatalf("PowerOptimizerPerms() did not fill the last group: %s %d", last.ID(), last.TotalLoad())
+	}
+	want := float64(s.TotalLoad()) * 2 / s.TotalPower()
+	if float64(last.TotalLoad()) > want {
+		t.Errorf("load of the last group %d exceeds its share %f", last.TotalLoad(), want)
+	}
+
+	if err := s.RemoveNode("n2"); err != nil {
+		t.Fatal(err)
+	}
+	if cgs, err = PowerOptimizerPerms(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ValidateGroups(cgs); err != nil {
+		t.Fatalf("PowerOptimizerPerms() returned invalid partition after removal: %v", err)
+	}
+}
+
+func TestOptimizersPins(t *testing.T) {
+	tests := []struct {
+		name string
+		of   balancer.OptimizerFunc
+	}{
+		{"PowerOptimizer", PowerOptimizer},
+		{"RangeOptimizer", RangeOptimizer},
+		{"PowerRangeOptimizer", PowerRangeOptimizer},
+		{"PowerOptimizerPerms", PowerOptimizerPerms},
+		{"PowerOptimizerGreedy", PowerOptimizerGreedy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSpace(t, 1000)
+			if err := s.Pin(0, 1000, "n3"); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Pin(3000, 3500, "n1", "n2"); err != nil {
+				t.Fatal(err)
+			}
+			cgs, err := tt.of(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.ValidateGroups(cgs); err != nil {
+				t.Fatalf("%s() returned invalid partition: %v", tt.name, err)
+			}
+			for _, cg := range cgs {
+				for id := range cg.Cells() {
+					if (id < 1000 && cg.ID() != "n3") || (id >= 3000 && id < 3500 && cg.ID() == "n3") {
+						t.Fatalf("%s() placed pinned cell %d on %s", tt.name, id, cg.ID())
+					}
+				}
+			}
+
+			s = newTestSpace(t, 100)
+			// Every node holds one range, so n1 and n2 can not follow n3.
+			for iter, ids := range [][]string{{"n1"}, {"n2"}, {"n3"}, {"n1", "n2"}} {
+				if err := s.Pin(uint64(iter)*10, uint64(iter+1)*10, ids...); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := tt.of(s); err == nil {
+				t.Fatalf("%s() expected error for unsatisfiable pins", tt.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package optimizer
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	balancer "github.com/visheratin/balancer"
+)
+
+// pinFitter moves ends of ranges of ordered cell groups, so every group holds only cells
+// which are allowed by pins and the rest of the groups are able to cover the space.
+type pinFitter struct {
+	pins  []balancer.Pin
+	ids   []string
+	total uint64
+}
+
+// newPinFitter returns the fitter for the groups. If pins can not be satisfied in the order
+// of the groups, the groups are stably reordered by the beginning of the first pin which
+// allows their node, and error is returned if pins can not be satisfied in that order too.
+func newPinFitter(s *balancer.Space, cgs []*balancer.CellGroup) (*pinFitter, []*balancer.CellGroup, error) {
+	f := &pinFitter{
+		pins:  s.Pins(),
+		total: s.TotalCells(),
+	}
+	f.setOrder(cgs)
+	if f.cover(0, 0) >= f.total {
+		return f, cgs, nil
+	}
+	anchors := make(map[string]uint64, len(cgs))
+	for _, cg := range cgs {
+		anchors[cg.ID()] = f.total
+		for _, p := range f.pins {
+			if p.Allows(cg.ID()) {
+				anchors[cg.ID()] = p.Range.Min
+				break
+			}
+		}
+	}
+	res := append([]*balancer.CellGroup(nil), cgs...)
+	sort.SliceStable(res, func(i, j int) bool {
+		return anchors[res[i].ID()] < anchors[res[j].ID()]
+	})
+	f.setOrder(res)
+	if pos := f.cover(0, 0); pos < f.total {
+		return nil, nil, f.unplaced(pos)
+	}
+	return f, res, nil
+}
+
+func (f *pinFitter) setOrder(cgs []*balancer.CellGroup) {
+	f.ids = make([]string, len(cgs))
+	for iter := range cgs {
+		f.ids[iter] = cgs[iter].ID()
+	}
+}
+
+// reach returns the farthest end of the range of the i-th group which starts at pos.
+func (f *pinFitter) reach(i int, pos uint64) uint64 {
+	for _, p := range f.pins {
+		if p.Range.Max <= pos || p.Allows(f.ids[i]) {
+			continue
+		}
+		if p.Range.Min <= pos {
+			return pos
+		}
+		return p.Range.Min
+	}
+	return f.total
+}
+
+// cover returns the farthest position covered by groups starting from the i-th one at pos.
+// Every group takes as many cells as it can, it never reduces the covered part.
+func (f *pinFitter) cover(i int, pos uint64) uint64 {
+	for ; i < len(f.ids) && pos < f.total; i++ {
+		pos = f.reach(i, pos)
+	}
+	return pos
+}
+
+// fit returns ends of ranges of groups closest to the desired ones.
+func (f *pinFitter) fit(ends []uint64) ([]uint64, error) {
+	res := make([]uint64, len(ends))
+	var start uint64
+	for iter := range ends {
+		end, err := f.clamp(iter, start, ends[iter])
+		if err != nil {
+			return nil, err
+		}
+		res[iter] = end
+		start = end
+	}
+	return res, nil
+}
+
+// clamp returns the end of the range of the i-th group which starts at start. The end is
+// the closest to desired one such that the group holds only allowed cells and the rest of
+// the groups are able to cover the space.
+func (f *pinFitter) clamp(i int, start, desired uint64) (uint64, error) {
+	if len(f.pins) == 0 {
+		return desired, nil
+	}
+	hi := f.reach(i, start)
+	if pos := f.cover(i+1, hi); pos < f.total {
+		return 0, f.unplaced(pos)
+	}
+	lo := start
+	for r := hi; lo < r; {
+		mid := lo + (r-lo)/2
+		if f.cover(i+1, mid) >= f.total {
+			r = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	switch {
+	case desired < lo:
+		return lo, nil
+	case desired > hi:
+		return hi, nil
+	}
+	return desired, nil
+}
+
+func (f *pinFitter) unplaced(pos uint64) error {
+	for _, p := range f.pins {
+		if p.Range.Min <= pos && pos < p.Range.Max {
+			return errors.Errorf("unable to place pinned cells [%d, %d) on nodes %v", p.Range.Min, p.Range.Max, p.Nodes)
+		}
+	}
+	return errors.Errorf("unable to place cells from %d without violating pins", pos)
+}
+
+func findPin(pins []balancer.Pin, cID uint64) (balancer.Pin, bool) {
+	iter := sort.Search(len(pins), func(i int) bool {
+		return pins[i].Range.Max > cID
+	})
+	if iter < len(pins) && pins[iter].Range.Min <= cID {
+		return pins[iter], true
+	}
+	return balancer.Pin{}, false
+}
@@ -1,98 +1,225 @@
 package optimizer
 
 import (
+	"sort"
+
 	"github.com/visheratin/balancer"
 )
 
+// PowerOptimizer splits cells of the space between nodes, so that loads of nodes are
+// proportional to their power. Ranges of pinned cells are moved to allowed nodes.
 func PowerOptimizer(s *balancer.Space) (res []*balancer.CellGroup, err error) {
-	var node balancer.Node
-
 	totalLoad := s.TotalLoad()
 	totalPower := s.TotalPower()
 	cgs := s.CellGroups()
 	cells := s.Cells()
+	if len(cgs) == 0 {
+		return res, nil
+	}
+	f, cgs, err := newPinFitter(s, cgs)
+	if err != nil {
+		return nil, err
+	}
 
+	// Nodes which did not get any cells receive empty ranges at the end of the space.
+	ends := make([]uint64, len(cgs))
+	for iter := range ends {
+		ends[iter] = s.TotalCells()
+	}
 	i := 0
-	node = cgs[i].Node()
-	cg := balancer.NewCellGroup(node)
-	p := node.Power().Get() / totalPower
-	l := float64(totalLoad) * p
-	var min, max uint64
+	l := float64(totalLoad) * cgs[i].Node().Power().Get() / totalPower
+	var load uint64
 	for iter := range cells {
-		cg.AddCell(cells[iter], false)
-		max = cells[iter].ID()
-		if float64(cg.TotalLoad()) >= l {
-			if i == (len(cgs) - 1) {
-				continue
-			}
-			if err := cg.SetRange(min, max); err != nil {
-				return nil, err
-			}
-			res = append(res, cg)
-			min = max
+		load += cells[iter].Load()
+		if float64(load) >= l && i < len(cgs)-1 {
+			ends[i] = cells[iter].ID() + 1
 			i++
-			cg = balancer.NewCellGroup(cgs[i].Node())
-			p = cg.Node().Power().Get() / totalPower
-			l = float64(totalLoad) * p
+			load = 0
+			l = float64(totalLoad) * cgs[i].Node().Power().Get() / totalPower
+		}
+	}
+	if ends, err = f.fit(ends); err != nil {
+		return nil, err
+	}
+	var min uint64
+	for iter := range cgs {
+		cg := balancer.NewCellGroup(cgs[iter].Node())
+		if err := cg.SetRange(min, ends[iter]); err != nil {
+			return nil, err
 		}
+		res = append(res, cg)
+		min = ends[iter]
+	}
+	i = 0
+	for iter := range cells {
+		for cells[iter].ID() >= ends[i] {
+			i++
+		}
+		res[i].AddCell(cells[iter], false)
 	}
-	res = append(res, cg)
 	return res, nil
 }
 
-// PowerOptimizerGreedy use prefilling of results slise.
-func PowerOptimizerGreedy(s *balancer.Space) (res []balancer.CellGroup, err error) {
+// PowerOptimizerGreedy use prefilling of results slise. Every group takes cells until
+// its share of load is exhausted, then ranges of pinned cells are moved to allowed nodes.
+func PowerOptimizerGreedy(s *balancer.Space) (res []*balancer.CellGroup, err error) {
 	totalLoad := float64(s.TotalLoad())
 	totalPower := s.TotalPower()
 	cgs := s.CellGroups()
 	cells := s.Cells()
+	if len(cgs) == 0 {
+		return res, nil
+	}
+	f, cgs, err := newPinFitter(s, cgs)
+	if err != nil {
+		return nil, err
+	}
 
 	lastCgIndex := len(cgs) - 1
-	res = make([]balancer.CellGroup, len(cgs))
+	res = make([]*balancer.CellGroup, len(cgs))
 	ws := make([]float64, len(cgs))
+	ends := make([]uint64, len(cgs))
 	i := 0
 
 	for iter := range res {
 		node := cgs[iter].Node()
-		res[iter].SetNode(node)
+		res[iter] = balancer.NewCellGroup(node)
 		ws[iter] = totalLoad * (node.Power().Get() / totalPower)
+		ends[iter] = s.TotalCells()
 	}
 
 	for iter := range cells {
-		res[i].AddCell(cells[iter], false)
 		ws[i] -= float64(cells[iter].Load())
 		if ws[i] <= 0 && i < lastCgIndex {
+			ends[i] = cells[iter].ID() + 1
 			i++
 		}
 	}
-
+	if ends, err = f.fit(ends); err != nil {
+		return nil, err
+	}
+	var min uint64
+	for iter := range res {
+		if err := res[iter].SetRange(min, ends[iter]); err != nil {
+			return nil, err
+		}
+		min = ends[iter]
+	}
+	i = 0
+	for iter := range cells {
+		for cells[iter].ID() >= ends[i] {
+			i++
+		}
+		res[i].AddCell(cells[iter], false)
+	}
 	return res, nil
 }
 
-// PowerOptimizerPerms fills last CellGroup will cells.
-// Function mutates cellGroups in space.
+// PowerOptimizerPerms fills last CellGroup with cells, e.g. the group of the node which
+// was added last. Cells are taken from the neighbouring group with the larger load, so only
+// the boundary between these groups moves and ranges of groups stay a valid partition.
+// Ranges of removed groups are joined to the next groups and ranges of pinned cells are
+// moved to allowed nodes. Function mutates cellGroups in space.
 func PowerOptimizerPerms(s *balancer.Space) (res []*balancer.CellGroup, err error) {
 	totalLoad := float64(s.TotalLoad())
 	totalPower := s.TotalPower()
 	cgs := s.CellGroups()
 	cells := s.Cells()
+	if len(cgs) == 0 {
+		return res, nil
+	}
 
-	lastCgIndex := len(cgs) - 1
-
-	l := totalLoad * (cgs[lastCgIndex].Node().Power().Get() / totalPower)
-	l -= float64(cgs[lastCgIndex].TotalLoad())
+	last := cgs[len(cgs)-1]
+	l := totalLoad * (last.Node().Power().Get() / totalPower)
+	l -= float64(last.TotalLoad())
 
+	ordered := append([]*balancer.CellGroup(nil), cgs...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		ri, rj := ordered[i].Range(), ordered[j].Range()
+		return ri.Min < rj.Min || (ri.Min == rj.Min && ri.Max < rj.Max)
+	})
+	ends := make([]uint64, len(ordered))
+	li := 0
+	for iter, cg := range ordered {
+		ends[iter] = cg.Range().Max
+		if cg == last {
+			li = iter
+		}
+	}
+	ends[len(ends)-1] = s.TotalCells()
+	loads := make([]uint64, len(ordered))
+	gi := 0
 	for iter := range cells {
-		if l <= 0 {
-			break
+		for cells[iter].ID() >= ends[gi] {
+			gi++
 		}
-		cl := float64(cells[iter].Load())
-		if cl > l {
-			continue
+		loads[gi] += cells[iter].Load()
+	}
+
+	left := li > 0 && (li == len(ordered)-1 || loads[li-1] >= loads[li+1])
+	right := !left && li < len(ordered)-1
+	var acc float64
+	switch {
+	case left:
+		var start uint64
+		if li > 1 {
+			start = ends[li-2]
+		}
+		for iter := len(cells) - 1; iter >= 0; iter-- {
+			id := cells[iter].ID()
+			if id >= ends[li-1] {
+				continue
+			}
+			if id < start || acc+float64(cells[iter].Load()) > l {
+				break
+			}
+			acc += float64(cells[iter].Load())
+			ends[li-1] = id
+		}
+	case right:
+		for iter := range cells {
+			id := cells[iter].ID()
+			if id < ends[li] {
+				continue
+			}
+			if id >= ends[li+1] || acc+float64(cells[iter].Load()) > l {
+				break
+			}
+			acc += float64(cells[iter].Load())
+			ends[li] = id + 1
 		}
-		l -= cl
-		cgs[lastCgIndex].AddCell(cells[iter], true)
 	}
 
+	desired := make(map[string]uint64, len(ordered))
+	for iter, cg := range ordered {
+		desired[cg.ID()] = ends[iter]
+	}
+	f, ordered, err := newPinFitter(s, ordered)
+	if err != nil {
+		return nil, err
+	}
+	for iter, cg := range ordered {
+		ends[iter] = desired[cg.ID()]
+	}
+	if ends, err = f.fit(ends); err != nil {
+		return nil, err
+	}
+	var min uint64
+	for iter, cg := range ordered {
+		if ends[iter] < min {
+			ends[iter] = min
+		}
+		if err := cg.SetRange(min, ends[iter]); err != nil {
+			return nil, err
+		}
+		min = ends[iter]
+	}
+	gi = 0
+	for iter := range cells {
+		for cells[iter].ID() >= ends[gi] {
+			gi++
+		}
+		ordered[gi].AddCell(cells[iter], true)
+	}
 	return cgs, nil
 }
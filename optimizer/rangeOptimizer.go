@@ -8,6 +8,8 @@ import (
 	balancer "github.com/visheratin/balancer"
 )
 
+// RangeOptimizer splits cells of the space between nodes proportionally to their power.
+// Ranges of pinned cells are moved to allowed nodes.
 func RangeOptimizer(s *balancer.Space) (res []*balancer.CellGroup, err error) {
 	totalPower := s.TotalPower()
 	cgs := s.CellGroups()
@@ -16,16 +18,21 @@ func RangeOptimizer(s *balancer.Space) (res []*balancer.CellGroup, err error) {
 	}
 	var max, min uint64
 	sort.Slice(cgs, func(i, j int) bool { return cgs[i].Node().ID() < cgs[j].Node().ID() })
+	f, cgs, err := newPinFitter(s, cgs)
+	if err != nil {
+		return nil, errors.Wrap(err, "range optimizer error")
+	}
 	for iter := 0; iter < len(cgs); iter++ {
 		min = max
 		p := cgs[iter].Node().Power().Get() / totalPower
 		max = min + uint64(math.Ceil(float64(s.TotalCells())*p))
-		if err := cgs[iter].SetRange(min, max); err != nil {
+		if max > s.TotalCells() || iter == len(cgs)-1 {
+			max = s.TotalCells()
+		}
+		if max, err = f.clamp(iter, min, max); err != nil {
 			return nil, errors.Wrap(err, "range optimizer error")
 		}
-	}
-	if max < s.TotalCells() {
-		if err := cgs[len(cgs)-1].SetRange(min, s.TotalCells()+1); err != nil {
+		if err := cgs[iter].SetRange(min, max); err != nil {
 			return nil, errors.Wrap(err, "range optimizer error")
 		}
 	}
@@ -41,6 +48,9 @@ func RangeOptimizer(s *balancer.Space) (res []*balancer.CellGroup, err error) {
 	return cgs, nil
 }
 
+// PowerRangeOptimizer splits cells of the space between nodes proportionally to their
+// power, so that loads of nodes do not exceed their capacity. Ranges of pinned cells are
+// moved to allowed nodes even if their capacity is exceeded.
 func PowerRangeOptimizer(s *balancer.Space) (res []*balancer.CellGroup, err error) {
 	cells := s.Cells()
 	totalPower := s.TotalPower()
@@ -54,42 +64,41 @@ func PowerRangeOptimizer(s *balancer.Space) (res []*balancer.CellGroup, err erro
 	sort.Slice(cgs, func(i, j int) bool {
 		return (cgs[i].Node().Capacity().Get() - float64(cgs[i].TotalLoad())) < (cgs[j].Node().Capacity().Get() - float64(cgs[j].TotalLoad()))
 	})
+	f, cgs, err := newPinFitter(s, cgs)
+	if err != nil {
+		return nil, errors.Wrap(err, "power range optimizer error")
+	}
 
 	for iter := 0; iter < len(cgs); iter++ {
 		min = max
 		p := cgs[iter].Node().Power().Get() / totalPower
-		f := cgs[iter].Node().Capacity().Get()
+		c := cgs[iter].Node().Capacity().Get()
 		max = min + uint64(math.Round(float64(s.TotalCells())*p))
+		if max > s.TotalCells() || iter == len(cgs)-1 {
+			max = s.TotalCells()
+		}
 
-		for citer := 0; citer < len(cells); citer++ {
-			if cells[citer].ID() > max {
+		for citer := 0; citer < len(cells) && iter < len(cgs)-1; citer++ {
+			if cells[citer].ID() >= max {
 				break
 			}
 			if cells[citer].ID() >= min {
-				f -= float64(cells[citer].Load())
-				if f <= 0 {
-					c := citer - 1
-					if c < 0 {
-						c = 0
-					}
+				c -= float64(cells[citer].Load())
+				if c <= 0 {
 					max = cells[citer].ID()
 					break
 				}
-				cgs[iter].AddCell(cells[citer], true)
 			}
 		}
-		if err := cgs[iter].SetRange(min, max); err != nil {
+		if max, err = f.clamp(iter, min, max); err != nil {
 			return nil, errors.Wrap(err, "power range optimizer error")
 		}
-	}
-
-	if max < s.TotalCells() {
-		if err := cgs[len(cgs)-1].SetRange(min, s.TotalCells()+1); err != nil {
-			return nil, errors.Wrap(err, "range optimizer error")
+		if err := cgs[iter].SetRange(min, max); err != nil {
+			return nil, errors.Wrap(err, "power range optimizer error")
 		}
 		for citer := range cells {
-			if cells[citer].ID() >= max {
-				cgs[len(cgs)-1].AddCell(cells[citer], true)
+			if id := cells[citer].ID(); id >= min && id < max {
+				cgs[iter].AddCell(cells[citer], true)
 			}
 		}
 	}
@@ -0,0 +1,331 @@
+package balancer
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+// Pin restricts placement of cells from the range to the allowed nodes, e.g. to keep data
+// in the region required by regulations or on the node with warm caches.
+//
+// Nodes - sorted identifiers of nodes which may hold cells of the range.
+type Pin struct {
+	Range Range
+	Nodes []string
+}
+
+// Allows reports whether the node may hold cells of the pin.
+func (p Pin) Allows(id string) bool {
+	iter := sort.SearchStrings(p.Nodes, id)
+	return iter < len(p.Nodes) && p.Nodes[iter] == id
+}
+
+// Pin restricts cells from the range [min, max) to the nodes. Pins do not move cells,
+// optimizers place pinned cells on allowed nodes and ValidateGroups rejects partitions
+// which violate pins. Overlapping pins are intersected, so the cell is allowed only on
+// nodes listed by all its pins. Error is returned if overlapping pins have no common nodes.
+//
+// Every node holds one contiguous range of cells, so cells pinned to a single node can not
+// be separated by cells which the node is not allowed to hold. Such pins are rejected.
+func (s *Space) Pin(min, max uint64, nodes ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if min >= max {
+		return errors.Errorf("min(%d) should be less then max(%d)", min, max)
+	}
+	if max > s.sfc.Length()+1 {
+		return errors.Errorf("max(%d) is beyond the last cell of the space", max)
+	}
+	return s.pin([]Range{newRange(min, max)}, nodes)
+}
+
+// PinRegion restricts cells of the region of the value space to the nodes. The region is
+// the box between values lo and hi mapped by the transform function of the space, so the
+// transform must preserve the order of values in every dimension.
+//
+// The region is pinned as at most maxRegionRanges ranges of cells. When exact ranges exceed
+// the limit, blocks of the curve on the border of the region are pinned entirely, so cells
+// just outside the region may be pinned too. Like Pin, PinRegion rejects regions of different
+// nodes which interleave on the curve, since every node holds one contiguous range of cells.
+func (s *Space) PinRegion(lo, hi []interface{}, nodes ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tf == nil {
+		return errors.New("transform function is not set")
+	}
+	min, err := s.tf(lo, s.sfc)
+	if err != nil {
+		return err
+	}
+	max, err := s.tf(hi, s.sfc)
+	if err != nil {
+		return err
+	}
+	if uint64(len(min)) != s.sfc.Dimensions() || uint64(len(max)) != s.sfc.Dimensions() {
+		return errors.Errorf("number of coordinates is not equal to dimensions(%d)", s.sfc.Dimensions())
+	}
+	for iter := range min {
+		if min[iter] > max[iter] {
+			min[iter], max[iter] = max[iter], min[iter]
+		}
+	}
+	rs, err := regionRanges(s.sfc, min, max)
+	if err != nil {
+		return err
+	}
+	return s.pin(rs, nodes)
+}
+
+// Unpin removes restrictions from cells of the range [min, max).
+func (s *Space) Unpin(min, max uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if min >= max {
+		return errors.Errorf("min(%d) should be less then max(%d)", min, max)
+	}
+	res := make([]Pin, 0, len(s.pins))
+	for _, p := range s.pins {
+		if p.Range.Max <= min || max <= p.Range.Min {
+			res = append(res, p)
+			continue
+		}
+		if p.Range.Min < min {
+			res = append(res, Pin{Range: newRange(p.Range.Min, min), Nodes: p.Nodes})
+		}
+		if max < p.Range.Max {
+			res = append(res, Pin{Range: newRange(max, p.Range.Max), Nodes: p.Nodes})
+		}
+	}
+	s.pins = res
+	return nil
+}
+
+// Pins returns restrictions of the space as sorted non-overlapping ranges.
+func (s *Space) Pins() []Pin {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Pin(nil), s.pins...)
+}
+
+// pin adds restrictions for the ranges. Ranges must be sorted and must not overlap.
+// It must be called under the lock of the space.
+func (s *Space) pin(rs []Range, nodes []string) error {
+	if len(nodes) == 0 {
+		return errors.New("pin must allow at least one node")
+	}
+	nodes = append([]string(nil), nodes...)
+	sort.Strings(nodes)
+	uniq := nodes[:1]
+	for _, id := range nodes[1:] {
+		if id != uniq[len(uniq)-1] {
+			uniq = append(uniq, id)
+		}
+	}
+	bounds := make([]uint64, 0, 2*(len(s.pins)+len(rs)))
+	for _, p := range s.pins {
+		bounds = append(bounds, p.Range.Min, p.Range.Max)
+	}
+	for _, r := range rs {
+		bounds = append(bounds, r.Min, r.Max)
+	}
+	sort.Slice(bounds, func(i, j int) bool {
+		return bounds[i] < bounds[j]
+	})
+	var res []Pin
+	var pi, ri int
+	for iter := 0; iter+1 < len(bounds); iter++ {
+		min, max := bounds[iter], bounds[iter+1]
+		if min == max {
+			continue
+		}
+		for pi < len(s.pins) && s.pins[pi].Range.Max <= min {
+			pi++
+		}
+		for ri < len(rs) && rs[ri].Max <= min {
+			ri++
+		}
+		old := pi < len(s.pins) && s.pins[pi].Range.Min <= min
+		added := ri < len(rs) && rs[ri].Min <= min
+		var ns []string
+		switch {
+		case old && added:
+			ns = intersect(s.pins[pi].Nodes, uniq)
+			if len(ns) == 0 {
+				return errors.Errorf("pinned cells [%d, %d) have no common nodes with %v", min, max, uniq)
+			}
+		case old:
+			ns = s.pins[pi].Nodes
+		case added:
+			ns = uniq
+		default:
+			continue
+		}
+		if l := len(res) - 1; l >= 0 && res[l].Range.Max == min && equalStrings(res[l].Nodes, ns) {
+			res[l].Range = newRange(res[l].Range.Min, max)
+			continue
+		}
+		res = append(res, Pin{Range: newRange(min, max), Nodes: ns})
+	}
+	if err := checkContiguous(res); err != nil {
+		return err
+	}
+	s.pins = res
+	return nil
+}
+
+// checkContiguous returns error if cells pinned to a single node are separated by cells
+// which the node is not allowed to hold. Every node holds one range of cells, so such pins
+// can not be satisfied by any partition.
+func checkContiguous(pins []Pin) error {
+	first := map[string]int{}
+	for iter, p := range pins {
+		if len(p.Nodes) != 1 {
+			continue
+		}
+		id := p.Nodes[0]
+		start, ok := first[id]
+		if !ok {
+			first[id] = iter
+			continue
+		}
+		for _, q := range pins[start+1 : iter] {
+			if !q.Allows(id) {
+				return errors.Errorf("pinned cells [%d, %d) on %v separate cells pinned to node %s, every node holds one range of cells",
+					q.Range.Min, q.Range.Max, q.Nodes, id)
+			}
+		}
+		first[id] = iter
+	}
+	return nil
+}
+
+// findPin returns the pin which restricts the cell.
+func findPin(pins []Pin, cID uint64) (Pin, bool) {
+	iter := sort.Search(len(pins), func(i int) bool {
+		return pins[i].Range.Max > cID
+	})
+	if iter < len(pins) && pins[iter].Range.Min <= cID {
+		return pins[iter], true
+	}
+	return Pin{}, false
+}
+
+// maxRegionRanges limits the number of ranges produced for one region by regionRanges.
+const maxRegionRanges = 4096
+
+// regionRanges returns sorted ranges of cells inside the box [lo, hi]. Aligned blocks of
+// the curve with the side of 2^k are contiguous ranges of cells, so the box is split into
+// the largest blocks which lie inside it. Blocks on the border of the box are split level
+// by level until the number of ranges would exceed maxRegionRanges, the remaining border
+// blocks are returned entirely.
+func regionRanges(sfc curve.Curve, lo, hi []uint64) ([]Range, error) {
+	dims := sfc.Dimensions()
+	buf := make([]uint64, dims)
+	var res []Range
+	add := func(corner []uint64, level uint64) error {
+		copy(buf, corner)
+		code, err := sfc.Encode(buf)
+		if err != nil {
+			return errors.Wrap(err, "cell encoding error")
+		}
+		mask := ^uint64(0)
+		if level*dims < 64 {
+			mask = uint64(1)<<(level*dims) - 1
+		}
+		res = append(res, newRange(code&^mask, (code|mask)+1))
+		return nil
+	}
+	// border contains corners of blocks which are partially inside the box.
+	var border [][]uint64
+	classify := func(corner []uint64, level uint64) error {
+		last := uint64(1)<<level - 1
+		inside := true
+		for iter := range corner {
+			if corner[iter] > hi[iter] || corner[iter]+last < lo[iter] {
+				return nil
+			}
+			if corner[iter] < lo[iter] || corner[iter]+last > hi[iter] {
+				inside = false
+			}
+		}
+		if inside {
+			return add(corner, level)
+		}
+		border = append(border, corner)
+		return nil
+	}
+	level := sfc.Bits()
+	if err := classify(make([]uint64, dims), level); err != nil {
+		return nil, err
+	}
+	for len(border) > 0 {
+		if dims >= 64 || uint64(len(border)) > uint64(maxRegionRanges-len(res))>>dims {
+			for _, corner := range border {
+				if err := add(corner, level); err != nil {
+					return nil, err
+				}
+			}
+			break
+		}
+		blocks := border
+		border = nil
+		level--
+		for _, corner := range blocks {
+			for child := uint64(0); child < 1<<dims; child++ {
+				next := append([]uint64(nil), corner...)
+				for iter := range next {
+					if child&(1<<uint64(iter)) != 0 {
+						next[iter] += 1 << level
+					}
+				}
+				if err := classify(next, level); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Min < res[j].Min
+	})
+	merged := res[:0]
+	for _, r := range res {
+		if l := len(merged) - 1; l >= 0 && merged[l].Max == r.Min {
+			merged[l] = newRange(merged[l].Min, r.Max)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged, nil
+}
+
+// intersect returns common elements of sorted slices.
+func intersect(a, b []string) []string {
+	var res []string
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			a = a[1:]
+		case a[0] > b[0]:
+			b = b[1:]
+		default:
+			res = append(res, a[0])
+			a, b = a[1:], b[1:]
+		}
+	}
+	return res
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for iter := range a {
+		if a[iter] != b[iter] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,249 @@
+package balancer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+func TestSpace_Pin(t *testing.T) {
+	s := newTestSpace(t, curve.Hilbert, 2, 2, "n1", "n2", "n3")
+	if err := s.Pin(0, 8, "n2", "n1", "n2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Pin(4, 12, "n3", "n2"); err != nil {
+		t.Fatal(err)
+	}
+	want := []Pin{
+		{Range: Range{Min: 0, Max: 4, Len: 4}, Nodes: []string{"n1", "n2"}},
+		{Range: Range{Min: 4, Max: 8, Len: 4}, Nodes: []string{"n2"}},
+		{Range: Range{Min: 8, Max: 12, Len: 4}, Nodes: []string{"n2", "n3"}},
+	}
+	if got := s.Pins(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Pins() = %v, want %v", got, want)
+	}
+	if err := s.Pin(6, 10, "n1"); err == nil {
+		t.Fatal("expected error for pins without common nodes")
+	}
+	if err := s.Pin(10, 17, "n1"); err == nil {
+		t.Fatal("expected error for range beyond the space")
+	}
+	if got := s.Pins(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("failed pin changed pins: %v", got)
+	}
+	if err := s.Unpin(2, 10); err != nil {
+		t.Fatal(err)
+	}
+	want = []Pin{
+		{Range: Range{Min: 0, Max: 2, Len: 2}, Nodes: []string{"n1", "n2"}},
+		{Range: Range{Min: 10, Max: 12, Len: 2}, Nodes: []string{"n2", "n3"}},
+	}
+	if got := s.Pins(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Pins() after Unpin = %v, want %v", got, want)
+	}
+}
+
+func TestSpace_PinRegion(t *testing.T) {
+	for _, cType := range []curve.CurveType{curve.Hilbert, curve.Morton} {
+		t.Run(cType.String(), func(t *testing.T) {
+			s := newTestSpace(t, cType, 2, 3, "n1", "n2")
+			lo, hi := []uint64{1, 2}, []uint64{5, 6}
+			if err := s.PinRegion([]interface{}{hi[0], lo[1]}, []interface{}{lo[0], hi[1]}, "n1"); err != nil {
+				t.Fatal(err)
+			}
+			pins := s.Pins()
+			for cID := uint64(0); cID < s.TotalCells(); cID++ {
+				coords, err := s.sfc.Decode(cID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				inside := coords[0] >= lo[0] && coords[0] <= hi[0] && coords[1] >= lo[1] && coords[1] <= hi[1]
+				if _, ok := findPin(pins, cID); ok != inside {
+					t.Fatalf("cell %d %v: pinned %v, inside region %v", cID, coords, ok, inside)
+				}
+			}
+		})
+	}
+}
+
+func TestSpace_PinInterleaved(t *testing.T) {
+	s := newTestSpace(t, curve.Hilbert, 2, 2, "n1", "n2")
+	if err := s.Pin(0, 4, "n1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Pin(4, 8, "n2"); err != nil {
+		t.Fatal(err)
+	}
+	want := s.Pins()
+	if err := s.Pin(8, 12, "n1"); err == nil {
+		t.Fatal("expected error for cells of n1 separated by cells of n2")
+	}
+	if got := s.Pins(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("failed pin changed pins: %v", got)
+	}
+	if err := s.Pin(8, 12, "n1", "n2"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cType := range []curve.CurveType{curve.Hilbert, curve.Morton} {
+		t.Run(cType.String(), func(t *testing.T) {
+			s := newTestSpace(t, cType, 2, 3, "n1", "n2")
+			if err := s.PinRegion([]interface{}{uint64(0), uint64(0)}, []interface{}{uint64(1), uint64(7)}, "n1"); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.PinRegion([]interface{}{uint64(2), uint64(0)}, []interface{}{uint64(7), uint64(7)}, "n2"); err == nil {
+				t.Fatal("expected error for interleaving regions of different nodes")
+			}
+		})
+	}
+}
+
+func TestRegionRangesWide(t *testing.T) {
+	for _, cType := range []curve.CurveType{curve.Hilbert, curve.Morton} {
+		t.Run(cType.String(), func(t *testing.T) {
+			sfc, err := curve.NewCurve(cType, 2, 24)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lo, hi := []uint64{12345, 67890}, []uint64{9876543, 8765432}
+			rs, err := regionRanges(sfc, lo, hi)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rs) > maxRegionRanges {
+				t.Fatalf("regionRanges() returned %d ranges, limit is %d", len(rs), maxRegionRanges)
+			}
+			pins := make([]Pin, len(rs))
+			for iter := range rs {
+				pins[iter] = Pin{Range: rs[iter]}
+			}
+			for _, coords := range [][]uint64{lo, hi, {lo[0], hi[1]}, {hi[0], lo[1]}, {1 << 22, 1 << 22}} {
+				cID, err := sfc.Encode(append([]uint64(nil), coords...))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, ok := findPin(pins, cID); !ok {
+					t.Fatalf("cell %v inside region is not covered", coords)
+				}
+			}
+		})
+	}
+}
+
+func TestSpace_ValidateGroupsPins(t *testing.T) {
+	s := newTestSpace(t, curve.Hilbert, 2, 2, "n1", "n2")
+	if err := s.Pin(2, 10, "n1"); err != nil {
+		t.Fatal(err)
+	}
+	n1, n2 := NewCellGroup(testNode{id: "n1"}), NewCellGroup(testNode{id: "n2"})
+	if err := n1.SetRange(0, 8); err != nil {
+		t.Fatal(err)
+	}
+	if err := n2.SetRange(8, 16); err != nil {
+		t.Fatal(err)
+	}
+	err := s.ValidateGroups([]*CellGroup{n1, n2})
+	want := &PartitionError{PinViolations: []Range{{Min: 8, Max: 10, Len: 2}}}
+	if !reflect.DeepEqual(err, want) {
+		t.Fatalf("ValidateGroups() = %v, want %v", err, want)
+	}
+	if err := n1.SetRange(0, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := n2.SetRange(10, 16); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ValidateGroups([]*CellGroup{n1, n2}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBalancer_RemoveNodePins(t *testing.T) {
+	// joinOptimizer gives all cells to the first group.
+	joinOptimizer := func(s *Space) ([]*CellGroup, error) {
+		cgs := s.CellGroups()
+		res := make([]*CellGroup, len(cgs))
+		for iter := range cgs {
+			res[iter] = NewCellGroup(cgs[iter].Node())
+		}
+		if err := res[0].SetRange(0, s.TotalCells()); err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+	b := &Balancer{
+		space: newMigrationSpace(t),
+		of:    joinOptimizer,
+	}
+	if err := b.Pin(0, 100, "n1"); err != nil {
+		t.Fatal(err)
+	}
+	before, err := b.space.RoutingState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.RemoveNode("n1"); err == nil {
+		t.Fatal("expected error for removal of the only node allowed by pin")
+	}
+	if _, ok := b.GetNode("n1"); !ok {
+		t.Fatal("failed RemoveNode removed the node")
+	}
+	after, err := b.space.RoutingState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(before, after) {
+		t.Fatalf("failed RemoveNode changed routing: %v", after)
+	}
+	checkBinding(t, b.space)
+
+	if err := b.RemoveNode("n2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.GetNode("n2"); ok || len(b.Nodes()) != 1 {
+		t.Fatal("RemoveNode did not remove the node")
+	}
+	checkBinding(t, b.space)
+}
+
+func TestBalancer_AddNodeFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		of   OptimizerFunc
+	}{
+		{"optimizer error", func(s *Space) ([]*CellGroup, error) {
+			return nil, errors.New("unable to optimize")
+		}},
+		{"invalid partition", func(s *Space) ([]*CellGroup, error) {
+			return s.CellGroups()[:1], nil
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Balancer{
+				space: newMigrationSpace(t),
+				of:    tt.of,
+			}
+			before, err := b.space.RoutingState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := b.AddNode(testNode{id: "n3", power: 1}, true); err == nil {
+				t.Fatal("expected error for failed optimization")
+			}
+			if _, ok := b.GetNode("n3"); ok {
+				t.Fatal("failed AddNode added the node")
+			}
+			after, err := b.space.RoutingState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(before, after) {
+				t.Fatalf("failed AddNode changed routing: %v", after)
+			}
+			checkBinding(t, b.space)
+		})
+	}
+}
@@ -4,3 +4,10 @@ package balancer
 type Power interface {
 	Get() float64
 }
+
+// PowerValue is a constant computational ability of the node.
+type PowerValue float64
+
+func (p PowerValue) Get() float64 {
+	return float64(p)
+}
@@ -0,0 +1,76 @@
+// Package render draws partitions of 2-dimensional spaces.
+package render
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/visheratin/balancer"
+	"github.com/visheratin/balancer/curve"
+)
+
+// Frame is a snapshot of the partition of a 2-dimensional space - ranges of cells owned
+// by the nodes and loads of the cells. Frames are detached from the space and can be
+// drawn after the space has changed.
+type Frame struct {
+	Epoch  uint64
+	Ranges []balancer.Range
+	Nodes  []string
+	Loads  map[uint64]uint64
+	sfc    curve.Curve
+}
+
+// NewFrame takes a snapshot of the space. The curve of the space must have 2 dimensions.
+func NewFrame(s *balancer.Space) (*Frame, error) {
+	rs, err := s.RoutingState()
+	if err != nil {
+		return nil, err
+	}
+	if rs.Dims != 2 {
+		return nil, errors.New("only 2-dimensional spaces can be rendered")
+	}
+	sfc, err := curve.NewCurve(rs.CurveType, rs.Dims, rs.Bits)
+	if err != nil {
+		return nil, err
+	}
+	f := &Frame{
+		Epoch:  rs.Epoch,
+		Ranges: rs.Ranges,
+		Nodes:  rs.Nodes,
+		Loads:  map[uint64]uint64{},
+		sfc:    sfc,
+	}
+	for _, c := range s.Cells() {
+		if l := c.Load(); l > 0 {
+			f.Loads[c.ID()] = l
+		}
+	}
+	return f, nil
+}
+
+// Side returns the number of cells along each dimension.
+func (f *Frame) Side() uint64 {
+	return f.sfc.DimensionSize() + 1
+}
+
+// Owner returns ID of the node which holds the cell.
+func (f *Frame) Owner(cID uint64) (string, bool) {
+	idx := sort.Search(len(f.Ranges), func(i int) bool {
+		return f.Ranges[i].Max > cID
+	})
+	if idx == len(f.Ranges) || f.Ranges[idx].Min > cID {
+		return "", false
+	}
+	return f.Nodes[idx], true
+}
+
+// MaxLoad returns the largest load of a single cell.
+func (f *Frame) MaxLoad() (max uint64) {
+	for _, l := range f.Loads {
+		if l > max {
+			max = l
+		}
+	}
+	return
+}
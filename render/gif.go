@@ -0,0 +1,56 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// WriteGIF renders frames as an animated GIF where every frame is shown for the delay.
+func WriteGIF(w io.Writer, frames []*Frame, opts Options, delay time.Duration) error {
+	if len(frames) == 0 {
+		return errors.New("no frames to render")
+	}
+	anim := &gif.GIF{
+		Image: make([]*image.Paletted, len(frames)),
+		Delay: make([]int, len(frames)),
+	}
+	for iter, f := range frames {
+		img, err := Draw(f, opts)
+		if err != nil {
+			return err
+		}
+		p := image.NewPaletted(img.Bounds(), palette.Plan9)
+		draw.Draw(p, p.Bounds(), img, img.Bounds().Min, draw.Src)
+		anim.Image[iter] = p
+		anim.Delay[iter] = int(delay / (10 * time.Millisecond))
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// SaveHistory saves every frame as SVG image step-NNN.svg and all frames as animation
+// history.gif to the directory.
+func SaveHistory(dir string, frames []*Frame, opts Options, delay time.Duration) error {
+	for iter, f := range frames {
+		if err := saveSVG(filepath.Join(dir, fmt.Sprintf("step-%03d.svg", iter)), f, opts); err != nil {
+			return err
+		}
+	}
+	file, err := os.Create(filepath.Join(dir, "history.gif"))
+	if err != nil {
+		return err
+	}
+	if err = WriteGIF(file, frames, opts, delay); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,91 @@
+package render
+
+import (
+	"image"
+	"image/draw"
+	"io"
+	"math"
+	"os"
+
+	"github.com/fogleman/gg"
+
+	"github.com/visheratin/balancer"
+)
+
+// Draw renders the frame into an image.
+func Draw(f *Frame, opts Options) (image.Image, error) {
+	dc, err := render(f, opts)
+	if err != nil {
+		return nil, err
+	}
+	return dc.Image(), nil
+}
+
+// WritePNG renders the frame and writes it to w in PNG format.
+func WritePNG(w io.Writer, f *Frame, opts Options) error {
+	dc, err := render(f, opts)
+	if err != nil {
+		return err
+	}
+	return dc.EncodePNG(w)
+}
+
+// SavePNG takes a snapshot of the space and saves it as PNG image to the file.
+func SavePNG(path string, s *balancer.Space, opts Options) error {
+	f, err := NewFrame(s)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = WritePNG(file, f, opts); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func render(f *Frame, opts Options) (*gg.Context, error) {
+	l := newLayout(f, opts)
+	img := image.NewRGBA(image.Rect(0, 0, l.size, l.size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(backgroundColor), image.Point{}, draw.Src)
+	cs, err := cells(f, l, opts)
+	if err != nil {
+		return nil, err
+	}
+	for x := uint64(0); x < l.units; x++ {
+		for y := uint64(0); y < l.units; y++ {
+			if c := cs[x*l.units+y]; c != nil {
+				draw.Draw(img, l.bounds(x, y), image.NewUniform(c), image.Point{}, draw.Src)
+			}
+		}
+	}
+	dc := gg.NewContextForRGBA(img)
+	points, err := path(f, l, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) > 0 {
+		for _, p := range points {
+			dc.LineTo(p[0], p[1])
+		}
+		dc.SetColor(pathColor)
+		dc.SetLineWidth(math.Max(1, l.px/8))
+		dc.Stroke()
+	}
+	if lines := gridlines(l, opts); len(lines) > 0 {
+		for _, line := range lines {
+			dc.DrawLine(line[0], line[1], line[2], line[3])
+		}
+		dc.SetColor(gridColor)
+		dc.SetLineWidth(1)
+		dc.Stroke()
+	}
+	if text := caption(f, opts); text != "" {
+		dc.SetColor(captionColor)
+		dc.DrawString(text, 4, 14)
+	}
+	return dc, nil
+}
@@ -0,0 +1,106 @@
+package render
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/visheratin/balancer"
+	"github.com/visheratin/balancer/curve"
+	"github.com/visheratin/balancer/optimizer"
+	"github.com/visheratin/balancer/transform"
+)
+
+func newTestSpace(t *testing.T) *balancer.Space {
+	nodes := []balancer.Node{
+		balancer.NewNode("n1", 1, 1000),
+		balancer.NewNode("n2", 1, 1000),
+		balancer.NewNode("n3", 1, 1000),
+	}
+	b, err := balancer.NewBalancer(curve.Hilbert, 2, 16, transform.SpaceTransform, optimizer.RangeOptimizer, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range [][]interface{}{{10.0, 20.0}, {-45.0, 100.0}, {60.0, -120.0}} {
+		if _, err := b.AddData(balancer.NewDataItem(string(rune('a'+i)), uint64(i+1)*10, v...)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return b.Space()
+}
+
+func TestDraw(t *testing.T) {
+	s := newTestSpace(t)
+	f, err := NewFrame(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Side() != 16 {
+		t.Fatalf("expected side 16, got %d", f.Side())
+	}
+	if len(f.Loads) != 3 || f.MaxLoad() != 30 {
+		t.Fatalf("unexpected loads %v", f.Loads)
+	}
+	img, err := Draw(f, Options{Size: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 64 || img.Bounds().Dy() != 64 {
+		t.Fatalf("unexpected image size %v", img.Bounds())
+	}
+	l := newLayout(f, Options{Size: 64})
+	for x := uint64(0); x < l.units; x++ {
+		for y := uint64(0); y < l.units; y++ {
+			cID, err := f.sfc.Encode(l.coords(x, y))
+			if err != nil {
+				t.Fatal(err)
+			}
+			id, ok := f.Owner(cID)
+			if !ok {
+				t.Fatalf("cell %d has no owner", cID)
+			}
+			px, py := l.rect(x, y)
+			got := color.RGBAModel.Convert(img.At(int(px)+1, int(py)+1))
+			if got != NodeColor(id) {
+				t.Fatalf("cell %d: expected color of %s, got %v", cID, id, got)
+			}
+		}
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	s := newTestSpace(t)
+	f, err := NewFrame(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []Options{
+		{Size: 64, Curve: true, Heat: true},
+		{Size: 8, Heat: true},
+		{Size: 128, Geo: true, Gridlines: 30},
+	}
+	for _, opts := range cases {
+		buf := &bytes.Buffer{}
+		if err := WritePNG(buf, f, opts); err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img.Bounds().Dx() != opts.Size {
+			t.Fatalf("expected width %d, got %d", opts.Size, img.Bounds().Dx())
+		}
+	}
+}
+
+func TestNewFrameDimensions(t *testing.T) {
+	b, err := balancer.NewBalancer(curve.Morton, 3, 4, transform.SpaceTransform, optimizer.RangeOptimizer, []balancer.Node{balancer.NewNode("n1", 1, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFrame(b.Space()); err == nil {
+		t.Fatal("expected error for 3-dimensional space")
+	}
+}
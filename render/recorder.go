@@ -0,0 +1,69 @@
+package render
+
+import (
+	"sync"
+
+	"github.com/visheratin/balancer"
+)
+
+// Recorder records the sequence of partitions of the space, e.g. after every Apply or
+// when nodes join or leave.
+type Recorder struct {
+	mu     sync.Mutex
+	frames []*Frame
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Record takes a snapshot of the space and appends it to the sequence.
+func (r *Recorder) Record(s *balancer.Space) error {
+	f, err := NewFrame(s)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.frames = append(r.frames, f)
+	return nil
+}
+
+// Watch records the current state of the space and then records it every time its
+// routing table changes until the returned function is called. Changes which happen
+// faster than snapshots are taken are merged into one frame.
+func (r *Recorder) Watch(s *balancer.Space) (func(), error) {
+	ch, cancel := s.Watch()
+	if err := r.Record(s); err != nil {
+		cancel()
+		return nil, err
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-ch:
+				// The space was already recorded once, so its curve is valid.
+				r.Record(s)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		close(done)
+		<-stopped
+	}, nil
+}
+
+// Frames returns recorded frames.
+func (r *Recorder) Frames() []*Frame {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res := make([]*Frame, len(r.frames))
+	copy(res, r.frames)
+	return res
+}
@@ -0,0 +1,83 @@
+package render
+
+import (
+	"bytes"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/visheratin/balancer"
+	"github.com/visheratin/balancer/curve"
+	"github.com/visheratin/balancer/optimizer"
+	"github.com/visheratin/balancer/transform"
+)
+
+func TestRecorder(t *testing.T) {
+	b, err := balancer.NewBalancer(curve.Hilbert, 2, 16, transform.SpaceTransform, optimizer.RangeOptimizer,
+		[]balancer.Node{balancer.NewNode("n1", 1, 1000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRecorder()
+	stop, err := r.Watch(b.Space())
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFrames := func(n int) {
+		deadline := time.Now().Add(5 * time.Second)
+		for len(r.Frames()) < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d frames, got %d", n, len(r.Frames()))
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	for iter, id := range []string{"n2", "n3"} {
+		if err := b.AddNode(balancer.NewNode(id, 1, 1000), true); err != nil {
+			t.Fatal(err)
+		}
+		waitFrames(iter + 2)
+	}
+	stop()
+	frames := r.Frames()
+	last := frames[len(frames)-1]
+	if last.Epoch != b.Epoch() {
+		t.Fatalf("expected last frame at epoch %d, got %d", b.Epoch(), last.Epoch)
+	}
+	if len(last.Nodes) != 3 {
+		t.Fatalf("expected 3 nodes in the last frame, got %v", last.Nodes)
+	}
+	if err := b.RemoveNode("n3"); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Frames()) != len(frames) {
+		t.Fatal("recorder must not record after stop")
+	}
+
+	buf := &bytes.Buffer{}
+	if err := WriteGIF(buf, frames, Options{Size: 32, Caption: true}, 500*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	anim, err := gif.DecodeAll(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anim.Image) != len(frames) || anim.Delay[0] != 50 {
+		t.Fatalf("unexpected animation: %d frames, delay %d", len(anim.Image), anim.Delay[0])
+	}
+
+	dir := t.TempDir()
+	if err := SaveHistory(dir, frames, Options{Size: 32}, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"step-000.svg", "history.gif"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := WriteGIF(buf, nil, Options{}, time.Second); err == nil {
+		t.Fatal("expected error for empty frames")
+	}
+}
@@ -0,0 +1,266 @@
+package render
+
+import (
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/visheratin/balancer/transform"
+)
+
+const (
+	// defaultSize is the size of the image side in pixels.
+	defaultSize = 512
+	// maxPathCells is the largest number of cells for which the curve path is drawn.
+	maxPathCells = 1 << 16
+)
+
+var (
+	backgroundColor = color.RGBA{R: 230, G: 230, B: 230, A: 255}
+	pathColor       = color.NRGBA{R: 40, G: 40, B: 40, A: 160}
+	gridColor       = color.NRGBA{R: 255, G: 255, B: 255, A: 200}
+	captionColor    = color.RGBA{A: 255}
+)
+
+// Options controls what is drawn.
+//
+// Size - size of the image side in pixels, 512 if not set. If the space has more cells
+// along a dimension than pixels, neighbouring cells are merged.
+//
+// Curve - draw the path of the curve through cell centers. The path is drawn only when
+// every cell is visible and the space has at most 65536 cells.
+//
+// Heat - shade cells by their load, heavier cells are darker.
+//
+// Geo - the space was built by a geo transform: the first dimension is latitude and the
+// second is longitude. Longitude is drawn horizontally and north is up.
+//
+// Gridlines - step in degrees between lat/lon gridlines for geo spaces, 0 disables them.
+//
+// Projection - projection of the geo transform of the space, latitude gridlines are drawn
+// at the rows where the transform places their latitudes.
+//
+// Caption - print the epoch of the frame in the corner of the image.
+type Options struct {
+	Size       int
+	Curve      bool
+	Heat       bool
+	Geo        bool
+	Gridlines  float64
+	Projection transform.Projection
+	Caption    bool
+}
+
+// layout maps cells of the frame to pixels.
+type layout struct {
+	size  int
+	units uint64
+	step  uint64
+	px    float64
+	geo   bool
+}
+
+func newLayout(f *Frame, opts Options) layout {
+	l := layout{
+		size: opts.Size,
+		geo:  opts.Geo,
+		step: 1,
+	}
+	if l.size <= 0 {
+		l.size = defaultSize
+	}
+	side := f.Side()
+	for side/l.step > uint64(l.size) {
+		l.step *= 2
+	}
+	l.units = side / l.step
+	l.px = float64(l.size) / float64(l.units)
+	return l
+}
+
+// xy returns horizontal and vertical unit indices of the cell coordinates.
+func (l layout) xy(coords []uint64) (uint64, uint64) {
+	if l.geo {
+		return coords[1] / l.step, coords[0] / l.step
+	}
+	return coords[0] / l.step, coords[1] / l.step
+}
+
+// coords returns cell coordinates of the unit with given indices.
+func (l layout) coords(x, y uint64) []uint64 {
+	if l.geo {
+		return []uint64{y * l.step, x * l.step}
+	}
+	return []uint64{x * l.step, y * l.step}
+}
+
+// rect returns top left corner of the unit in pixels. Vertical axis points up.
+func (l layout) rect(x, y uint64) (float64, float64) {
+	return float64(x) * l.px, float64(l.size) - float64(y+1)*l.px
+}
+
+// bounds returns pixel bounds of the unit.
+func (l layout) bounds(x, y uint64) image.Rectangle {
+	px, py := l.rect(x, y)
+	return image.Rect(int(px), int(py), int(px+l.px), int(py+l.px))
+}
+
+// cells returns colors of all units of the layout, the unit (x, y) has index
+// x*units+y. Units which are not held by any node have nil color.
+func cells(f *Frame, l layout, opts Options) ([]color.Color, error) {
+	heat, err := unitLoads(f, l)
+	if err != nil {
+		return nil, err
+	}
+	var maxHeat uint64
+	for _, v := range heat {
+		if v > maxHeat {
+			maxHeat = v
+		}
+	}
+	res := make([]color.Color, l.units*l.units)
+	for x := uint64(0); x < l.units; x++ {
+		for y := uint64(0); y < l.units; y++ {
+			cID, err := f.sfc.Encode(l.coords(x, y))
+			if err != nil {
+				return nil, err
+			}
+			id, ok := f.Owner(cID)
+			if !ok {
+				continue
+			}
+			c := NodeColor(id)
+			if opts.Heat && maxHeat > 0 {
+				c = shade(c, math.Log1p(float64(heat[x*l.units+y]))/math.Log1p(float64(maxHeat)))
+			}
+			res[x*l.units+y] = c
+		}
+	}
+	return res, nil
+}
+
+// unitLoads sums loads of the cells in every unit of the layout.
+func unitLoads(f *Frame, l layout) ([]uint64, error) {
+	res := make([]uint64, l.units*l.units)
+	buf := make([]uint64, 2)
+	for cID, load := range f.Loads {
+		coords, err := decode(f, buf, cID)
+		if err != nil {
+			return nil, err
+		}
+		x, y := l.xy(coords)
+		res[x*l.units+y] += load
+	}
+	return res, nil
+}
+
+// decode decodes the cell into the buffer. The buffer is cleared because curves
+// accumulate coordinate bits in it.
+func decode(f *Frame, buf []uint64, cID uint64) ([]uint64, error) {
+	for iter := range buf {
+		buf[iter] = 0
+	}
+	return f.sfc.DecodeWithBuffer(buf, cID)
+}
+
+// path returns centers of the cells in the curve order in pixels. It returns nil if the
+// path should not be drawn.
+func path(f *Frame, l layout, opts Options) ([][2]float64, error) {
+	if !opts.Curve || l.step != 1 || f.sfc.Length() >= maxPathCells {
+		return nil, nil
+	}
+	res := make([][2]float64, 0, f.sfc.Length()+1)
+	buf := make([]uint64, 2)
+	for code := uint64(0); code <= f.sfc.Length(); code++ {
+		coords, err := decode(f, buf, code)
+		if err != nil {
+			return nil, err
+		}
+		px, py := l.rect(l.xy(coords))
+		res = append(res, [2]float64{px + l.px/2, py + l.px/2})
+	}
+	return res, nil
+}
+
+// gridlines returns lat/lon gridlines as pairs of points in pixels.
+func gridlines(l layout, opts Options) [][4]float64 {
+	if !opts.Geo || opts.Gridlines <= 0 {
+		return nil
+	}
+	var res [][4]float64
+	size := float64(l.size)
+	for lat := -90 + opts.Gridlines; lat < 90; lat += opts.Gridlines {
+		y := size - opts.Projection.Latitude(lat)*size
+		res = append(res, [4]float64{0, y, size, y})
+	}
+	for lon := -180 + opts.Gridlines; lon < 180; lon += opts.Gridlines {
+		x := (lon + 180) / 360 * size
+		res = append(res, [4]float64{x, 0, x, size})
+	}
+	return res
+}
+
+// caption returns the text drawn in the corner of the image.
+func caption(f *Frame, opts Options) string {
+	if !opts.Caption {
+		return ""
+	}
+	return fmt.Sprintf("epoch %d", f.Epoch)
+}
+
+// NodeColor returns the color of the node. The color depends only on the node ID, so the
+// node keeps its color across frames.
+func NodeColor(id string) color.Color {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	// Similar IDs like "node-1" and "node-2" have close hashes, they are mixed to get
+	// distinct hues.
+	v := h.Sum32()
+	v ^= v >> 16
+	v *= 0x85ebca6b
+	v ^= v >> 13
+	v *= 0xc2b2ae35
+	v ^= v >> 16
+	return hsv(float64(v%360), 0.55, 0.95)
+}
+
+// shade darkens the color proportionally to the intensity in [0, 1].
+func shade(c color.Color, intensity float64) color.Color {
+	r, g, b, _ := c.RGBA()
+	k := 1 - 0.7*intensity
+	return color.RGBA{
+		R: uint8(float64(r>>8) * k),
+		G: uint8(float64(g>>8) * k),
+		B: uint8(float64(b>>8) * k),
+		A: 255,
+	}
+}
+
+func hsv(h, s, v float64) color.Color {
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := v - c
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return color.RGBA{
+		R: uint8((r + m) * 255),
+		G: uint8((g + m) * 255),
+		B: uint8((b + m) * 255),
+		A: 255,
+	}
+}
@@ -0,0 +1,105 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"os"
+
+	"github.com/visheratin/balancer"
+)
+
+// WriteSVG renders the frame and writes it to w in SVG format. Neighbouring units of the
+// same color in a row are merged into a single rectangle.
+func WriteSVG(w io.Writer, f *Frame, opts Options) error {
+	l := newLayout(f, opts)
+	cs, err := cells(f, l, opts)
+	if err != nil {
+		return err
+	}
+	points, err := path(f, l, opts)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		l.size, l.size, l.size, l.size)
+	fmt.Fprintf(bw, "<title>epoch %d</title>\n", f.Epoch)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="%s"/>`+"\n", l.size, l.size, hex(backgroundColor))
+	for y := uint64(0); y < l.units; y++ {
+		for x := uint64(0); x < l.units; {
+			c := cs[x*l.units+y]
+			end := x + 1
+			for end < l.units && sameColor(cs[end*l.units+y], c) {
+				end++
+			}
+			if c != nil {
+				px, py := l.rect(x, y)
+				fmt.Fprintf(bw, `<rect x="%g" y="%g" width="%g" height="%g" fill="%s"/>`+"\n",
+					px, py, float64(end-x)*l.px, l.px, hex(c))
+			}
+			x = end
+		}
+	}
+	if len(points) > 0 {
+		fmt.Fprintf(bw, `<polyline fill="none" stroke="%s" stroke-opacity="%g" stroke-width="%g" points="`,
+			hex(pathColor), opacity(pathColor), math.Max(1, l.px/8))
+		for iter, p := range points {
+			if iter > 0 {
+				bw.WriteByte(' ')
+			}
+			fmt.Fprintf(bw, "%g,%g", p[0], p[1])
+		}
+		bw.WriteString("\"/>\n")
+	}
+	for _, line := range gridlines(l, opts) {
+		fmt.Fprintf(bw, `<line x1="%g" y1="%g" x2="%g" y2="%g" stroke="%s" stroke-opacity="%g"/>`+"\n",
+			line[0], line[1], line[2], line[3], hex(gridColor), opacity(gridColor))
+	}
+	if text := caption(f, opts); text != "" {
+		fmt.Fprintf(bw, `<text x="4" y="14" font-family="monospace" font-size="12" fill="%s">%s</text>`+"\n",
+			hex(captionColor), text)
+	}
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
+
+// SaveSVG takes a snapshot of the space and saves it as SVG image to the file.
+func SaveSVG(path string, s *balancer.Space, opts Options) error {
+	f, err := NewFrame(s)
+	if err != nil {
+		return err
+	}
+	return saveSVG(path, f, opts)
+}
+
+func saveSVG(path string, f *Frame, opts Options) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = WriteSVG(file, f, opts); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func sameColor(a, b color.Color) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return color.NRGBAModel.Convert(a) == color.NRGBAModel.Convert(b)
+}
+
+// hex returns the color in #rrggbb form without the alpha channel.
+func hex(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B)
+}
+
+func opacity(c color.Color) float64 {
+	return float64(color.NRGBAModel.Convert(c).(color.NRGBA).A) / 255
+}
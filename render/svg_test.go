@@ -0,0 +1,68 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/visheratin/balancer/transform"
+)
+
+func TestWriteSVG(t *testing.T) {
+	s := newTestSpace(t)
+	f, err := NewFrame(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	err = WriteSVG(buf, f, Options{Size: 64, Curve: true, Heat: true, Geo: true, Gridlines: 45, Caption: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := xml.NewDecoder(bytes.NewReader(buf.Bytes()))
+	counts := map[string]int{}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		if el, ok := tok.(xml.StartElement); ok {
+			counts[el.Name.Local]++
+		}
+	}
+	if counts["svg"] != 1 || counts["polyline"] != 1 || counts["text"] != 1 {
+		t.Fatalf("unexpected elements %v", counts)
+	}
+	// 3 horizontal and 7 vertical gridlines.
+	if counts["line"] != 10 {
+		t.Fatalf("expected 10 gridlines, got %d", counts["line"])
+	}
+	// Runs of the same color are merged, so there are fewer rectangles than cells.
+	if counts["rect"] < 2 || counts["rect"] > 16*16 {
+		t.Fatalf("unexpected number of rectangles %d", counts["rect"])
+	}
+	if !strings.Contains(buf.String(), hex(NodeColor("n1"))) {
+		t.Fatal("color of node n1 is missing")
+	}
+}
+
+func TestGridlinesProjection(t *testing.T) {
+	l := layout{size: 180}
+	opts := Options{Geo: true, Gridlines: 60}
+	for _, p := range []transform.Projection{transform.Linear, transform.WebMercator, transform.EqualArea} {
+		opts.Projection = p
+		lines := gridlines(l, opts)
+		// Latitude 30 is the second horizontal line.
+		y := lines[1][1]
+		exp := 180 - p.Latitude(30)*180
+		if math.Abs(y-exp) > 1e-9 {
+			t.Fatalf("%s: expected latitude 30 at %f, got %f", p, exp, y)
+		}
+	}
+	opts.Projection = transform.WebMercator
+	if y := gridlines(l, opts)[1][1]; math.Abs(y-60) < 1 {
+		t.Fatal("latitude of Web Mercator gridline is not projected")
+	}
+}
@@ -0,0 +1,356 @@
+package balancer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+// routingStateVersion is the version of the binary format of RoutingState.
+const routingStateVersion = 2
+
+// RoutingState is the snapshot of the routing table of the space which can be exported
+// to clients and loaded into Router.
+//
+// Transform - identity of the transform, Transformer.Identity of the transformer set by
+// SetTransformer or the name of the transform function. Method values and closures have
+// no identity, because differently configured transforms share their names.
+//
+// Ranges - sorted non-overlapping ranges of cells, Nodes[i] holds cells from Ranges[i].
+//
+// Pending - sorted non-overlapping ranges of cells which are being moved by the migration,
+// writes to cells from Pending[i] go to PendingNodes[i] as well as to their current node.
+// Every pending range lies inside one of Ranges.
+type RoutingState struct {
+	CurveType    curve.CurveType
+	Dims         uint64
+	Bits         uint64
+	Transform    string
+	Epoch        uint64
+	Ranges       []Range
+	Nodes        []string
+	Pending      []Range
+	PendingNodes []string
+}
+
+// RoutingState returns the snapshot of the current routing table of the space.
+func (s *Space) RoutingState() (RoutingState, error) {
+	cType, err := curve.TypeOf(s.sfc)
+	if err != nil {
+		return RoutingState{}, err
+	}
+	rt := s.routingTable()
+	s.mu.Lock()
+	tfID := s.tfID
+	if tfID == "" {
+		tfID = transformName(s.tf)
+	}
+	s.mu.Unlock()
+	res := RoutingState{
+		CurveType: cType,
+		Dims:      s.sfc.Dimensions(),
+		Bits:      s.sfc.Bits(),
+		Transform: tfID,
+		Epoch:     rt.epoch,
+		Ranges:    make([]Range, len(rt.mins)),
+		Nodes:     make([]string, len(rt.nodes)),
+	}
+	for iter := range rt.mins {
+		res.Ranges[iter] = Range{
+			Min: rt.mins[iter],
+			Max: rt.maxs[iter],
+			Len: rt.maxs[iter] - rt.mins[iter],
+		}
+		res.Nodes[iter] = rt.nodes[iter].ID()
+	}
+	for iter := range rt.pmins {
+		res.Pending = append(res.Pending, newRange(rt.pmins[iter], rt.pmaxs[iter]))
+		res.PendingNodes = append(res.PendingNodes, rt.pending[iter].ID())
+	}
+	return res, nil
+}
+
+// MarshalBinary encodes the state into compact binary form. Ranges must be sorted and
+// must not overlap, they are delta-encoded and node IDs are stored once.
+func (rs RoutingState) MarshalBinary() ([]byte, error) {
+	if len(rs.Ranges) != len(rs.Nodes) || len(rs.Pending) != len(rs.PendingNodes) {
+		return nil, errors.New("number of ranges must match number of nodes")
+	}
+	buf := &bytes.Buffer{}
+	tmp := make([]byte, binary.MaxVarintLen64)
+	put := func(v uint64) {
+		buf.Write(tmp[:binary.PutUvarint(tmp, v)])
+	}
+	putString := func(v string) {
+		put(uint64(len(v)))
+		buf.WriteString(v)
+	}
+	put(routingStateVersion)
+	put(uint64(rs.CurveType))
+	put(rs.Dims)
+	put(rs.Bits)
+	putString(rs.Transform)
+	put(rs.Epoch)
+	ids := map[string]uint64{}
+	var names []string
+	for _, n := range append(append([]string(nil), rs.Nodes...), rs.PendingNodes...) {
+		if _, ok := ids[n]; !ok {
+			ids[n] = uint64(len(names))
+			names = append(names, n)
+		}
+	}
+	put(uint64(len(names)))
+	for _, n := range names {
+		putString(n)
+	}
+	putRanges := func(rgs []Range, nodes []string) error {
+		put(uint64(len(rgs)))
+		var prev, end uint64
+		for iter, r := range rgs {
+			if r.Min < end || r.Max < r.Min {
+				return errors.Errorf("range %d [%d, %d) is not sorted or overlaps the previous one", iter, r.Min, r.Max)
+			}
+			end = r.Max
+			put(r.Min - prev)
+			put(r.Max - r.Min)
+			put(ids[nodes[iter]])
+			prev = r.Min
+		}
+		return nil
+	}
+	if err := putRanges(rs.Ranges, rs.Nodes); err != nil {
+		return nil, err
+	}
+	if err := putRanges(rs.Pending, rs.PendingNodes); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the state encoded by MarshalBinary.
+func (rs *RoutingState) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	var err error
+	get := func() uint64 {
+		if err != nil {
+			return 0
+		}
+		var v uint64
+		v, err = binary.ReadUvarint(r)
+		return v
+	}
+	getString := func() string {
+		l := get()
+		if err != nil {
+			return ""
+		}
+		if l > uint64(r.Len()) {
+			err = io.ErrUnexpectedEOF
+			return ""
+		}
+		b := make([]byte, l)
+		_, err = io.ReadFull(r, b)
+		return string(b)
+	}
+	if v := get(); err == nil && v != routingStateVersion {
+		return errors.Errorf("unsupported routing state version %d", v)
+	}
+	res := RoutingState{}
+	res.CurveType = curve.CurveType(get())
+	res.Dims = get()
+	res.Bits = get()
+	res.Transform = getString()
+	res.Epoch = get()
+	count := get()
+	if err == nil && count > uint64(r.Len()) {
+		err = io.ErrUnexpectedEOF
+	}
+	names := make([]string, 0, count)
+	for iter := uint64(0); iter < count && err == nil; iter++ {
+		names = append(names, getString())
+	}
+	getRanges := func() ([]Range, []string) {
+		count := get()
+		if err == nil && count > uint64(r.Len()) {
+			err = io.ErrUnexpectedEOF
+		}
+		var rgs []Range
+		var nodes []string
+		var prev, end uint64
+		for iter := uint64(0); iter < count && err == nil; iter++ {
+			min := prev + get()
+			l := get()
+			idx := get()
+			if err == nil && idx >= uint64(len(names)) {
+				err = errors.Errorf("node index %d is out of range", idx)
+			}
+			if err == nil && (min < end || min+l < min) {
+				err = errors.Errorf("range %d [%d, %d) overlaps the previous one", iter, min, min+l)
+			}
+			end = min + l
+			if err != nil {
+				break
+			}
+			rgs = append(rgs, Range{Min: min, Max: min + l, Len: l})
+			nodes = append(nodes, names[idx])
+			prev = min
+		}
+		return rgs, nodes
+	}
+	res.Ranges, res.Nodes = getRanges()
+	res.Pending, res.PendingNodes = getRanges()
+	if err != nil {
+		return errors.Wrap(err, "routing state decoding error")
+	}
+	*rs = res
+	return nil
+}
+
+// Router locates data items using the exported routing state without access to the balancer.
+// It is read-only and safe for concurrent use.
+type Router struct {
+	state   RoutingState
+	sfc     curve.Curve
+	tf      TransformFunc
+	mins    []uint64
+	maxs    []uint64
+	writes  [][]string
+	pmins   []uint64
+	pmaxs   []uint64
+	pwrites [][]string
+}
+
+// NewRouter creates router from the routing state encoded by RoutingState.MarshalBinary.
+// tf must be the same transform function which is used by the balancer. Method values
+// and closures have no identity, NewTransformerRouter is used for them.
+func NewRouter(data []byte, tf TransformFunc) (*Router, error) {
+	if tf == nil {
+		return nil, errors.New("transform function is not set")
+	}
+	return newRouter(data, tf, transformName(tf))
+}
+
+// NewTransformerRouter creates router from the routing state exported by the balancer
+// whose transform is set by SetTransformer. Identity of t must match the exported one.
+func NewTransformerRouter(data []byte, t Transformer) (*Router, error) {
+	if t == nil {
+		return nil, errors.New("transformer is not set")
+	}
+	return newRouter(data, t.Transform, t.Identity())
+}
+
+func newRouter(data []byte, tf TransformFunc, id string) (*Router, error) {
+	rs := RoutingState{}
+	if err := rs.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	if rs.Transform == "" {
+		return nil, errors.New("exported transform has no identity, it must be set by SetTransformer")
+	}
+	if id == "" {
+		return nil, errors.New("transform function has no identity, it must be passed as Transformer")
+	}
+	if id != rs.Transform {
+		return nil, errors.Errorf("transform %s does not match exported transform %s", id, rs.Transform)
+	}
+	sfc, err := curve.NewCurve(rs.CurveType, rs.Dims, rs.Bits)
+	if err != nil {
+		return nil, err
+	}
+	r := &Router{
+		state:   rs,
+		sfc:     sfc,
+		tf:      tf,
+		mins:    make([]uint64, len(rs.Ranges)),
+		maxs:    make([]uint64, len(rs.Ranges)),
+		writes:  make([][]string, len(rs.Ranges)),
+		pmins:   make([]uint64, len(rs.Pending)),
+		pmaxs:   make([]uint64, len(rs.Pending)),
+		pwrites: make([][]string, len(rs.Pending)),
+	}
+	for iter := range rs.Ranges {
+		r.mins[iter] = rs.Ranges[iter].Min
+		r.maxs[iter] = rs.Ranges[iter].Max
+		r.writes[iter] = rs.Nodes[iter : iter+1 : iter+1]
+	}
+	for iter := range rs.Pending {
+		r.pmins[iter] = rs.Pending[iter].Min
+		r.pmaxs[iter] = rs.Pending[iter].Max
+		i, ok := searchRanges(r.mins, r.maxs, r.pmins[iter])
+		if !ok || r.pmaxs[iter] > r.maxs[i] {
+			return nil, errors.Errorf("pending range [%d, %d) does not lie inside a routed range", r.pmins[iter], r.pmaxs[iter])
+		}
+		r.pwrites[iter] = []string{rs.Nodes[i], rs.PendingNodes[iter]}
+	}
+	return r, nil
+}
+
+// Epoch returns the epoch of the loaded routing state.
+func (r *Router) Epoch() uint64 {
+	return r.state.Epoch
+}
+
+// Locate returns the ID of the node which holds the data item.
+func (r *Router) Locate(d DataItem) (string, error) {
+	i, _, err := r.search(d)
+	if err != nil {
+		return "", err
+	}
+	return r.state.Nodes[i], nil
+}
+
+// LocateWrite returns IDs of nodes which must receive writes of the data item. While the cell
+// of the item is being moved by the migration, they are the old and the new node, otherwise
+// only the node returned by Locate. The slice is shared and must not be modified.
+func (r *Router) LocateWrite(d DataItem) ([]string, error) {
+	i, cID, err := r.search(d)
+	if err != nil {
+		return nil, err
+	}
+	if j, ok := searchRanges(r.pmins, r.pmaxs, cID); ok {
+		return r.pwrites[j], nil
+	}
+	return r.writes[i], nil
+}
+
+// search returns the index of the range which holds the cell of the data item and the cell.
+func (r *Router) search(d DataItem) (int, uint64, error) {
+	coords, err := r.tf(d.Values(), r.sfc)
+	if err != nil {
+		return 0, 0, err
+	}
+	cID, err := r.sfc.Encode(coords)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "item encoding error")
+	}
+	i, ok := searchRanges(r.mins, r.maxs, cID)
+	if !ok {
+		return 0, 0, errors.Errorf("unable to find node for cell (cID=%v  d=%s)", cID, d.ID())
+	}
+	return i, cID, nil
+}
+
+// closureName matches names of anonymous functions, e.g. "pkg.NewCompositeTransform.func1".
+var closureName = regexp.MustCompile(`\.func\d+(\.\d+)*$`)
+
+// transformName returns the name of the transform function which identifies it in the
+// exported routing state. Method values and closures share names between differently
+// configured transforms, so they have no identity and empty name is returned.
+func transformName(tf TransformFunc) string {
+	if tf == nil {
+		return ""
+	}
+	f := runtime.FuncForPC(reflect.ValueOf(tf).Pointer())
+	if f == nil || strings.HasSuffix(f.Name(), "-fm") || closureName.MatchString(f.Name()) {
+		return ""
+	}
+	return f.Name()
+}
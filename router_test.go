@@ -0,0 +1,191 @@
+package balancer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/visheratin/balancer/curve"
+	"github.com/visheratin/balancer/transform"
+)
+
+func TestRoutingState_MarshalBinary(t *testing.T) {
+	rs := RoutingState{
+		CurveType:    curve.Morton,
+		Dims:         3,
+		Bits:         10,
+		Transform:    "github.com/visheratin/balancer/transform.KVTransform",
+		Epoch:        42,
+		Ranges:       []Range{{0, 100, 100}, {100, 250, 150}, {250, 1 << 30, 1<<30 - 250}},
+		Nodes:        []string{"n1", "n2", "n1"},
+		Pending:      []Range{{10, 20, 10}, {100, 110, 10}},
+		PendingNodes: []string{"n3", "n1"},
+	}
+	data, err := rs.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := RoutingState{}
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, rs) {
+		t.Errorf("UnmarshalBinary() = %+v, want %+v", got, rs)
+	}
+	for l := 0; l < len(data); l++ {
+		if err := got.UnmarshalBinary(data[:l]); err == nil {
+			t.Fatalf("UnmarshalBinary() expected error for truncated data of length %d", l)
+		}
+	}
+}
+
+func TestRoutingState_UnmarshalBinaryOverlap(t *testing.T) {
+	rs := RoutingState{
+		Transform: "t",
+		Ranges:    []Range{{0, 100, 100}, {50, 150, 100}},
+		Nodes:     []string{"n1", "n2"},
+	}
+	if _, err := rs.MarshalBinary(); err == nil {
+		t.Error("MarshalBinary() expected error for overlapping ranges")
+	}
+	// Ranges are encoded by hand, because MarshalBinary rejects them.
+	data := []byte{routingStateVersion, 0, 0, 0, 1, 't', 0, 2, 2, 'n', '1', 2, 'n', '2', 2, 0, 100, 0, 50, 100, 1, 0}
+	if err := rs.UnmarshalBinary(data); err == nil {
+		t.Error("UnmarshalBinary() expected error for overlapping ranges")
+	}
+	data[18] = 100
+	if err := rs.UnmarshalBinary(data); err != nil {
+		t.Errorf("UnmarshalBinary() unexpected error for adjacent ranges: %v", err)
+	}
+}
+
+func TestRouter_Transformer(t *testing.T) {
+	s := newTestSpace(t, curve.Hilbert, 2, 4, "n1", "n2")
+	qt := &transform.QuantileTransform{Boundaries: [][]float64{{0, 5, 15}, {0, 10, 15}}}
+	if err := s.SetTransformer(transform.Named{ID: "coords", Func: coordsTransform}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := (&Balancer{space: s}).ExportRoutes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewTransformerRouter(data, transform.Named{ID: "coords", Func: coordsTransform}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRouter(data, coordsTransform); err == nil {
+		t.Error("NewRouter() expected error for transform with another identity")
+	}
+	if _, err := NewTransformerRouter(data, qt); err == nil {
+		t.Error("NewTransformerRouter() expected error for transform with another identity")
+	}
+
+	other := &transform.QuantileTransform{Boundaries: [][]float64{{0, 6, 15}, {0, 10, 15}}}
+	if qt.Identity() == other.Identity() {
+		t.Error("transforms with different boundaries have equal identities")
+	}
+	s = newTestSpace(t, curve.Hilbert, 2, 4, "n1", "n2")
+	s.tf = qt.Transform
+	if data, err = (&Balancer{space: s}).ExportRoutes(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRouter(data, qt.Transform); err == nil {
+		t.Error("NewRouter() expected error for method value without identity")
+	}
+	if err := s.SetTransformer(qt); err != nil {
+		t.Fatal(err)
+	}
+	if data, err = (&Balancer{space: s}).ExportRoutes(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewTransformerRouter(data, other); err == nil {
+		t.Error("NewTransformerRouter() expected error for transform with other boundaries")
+	}
+	if _, err := NewTransformerRouter(data, qt); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddData(testItem{"d", 1, []uint64{1, 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetTransformer(other); err == nil {
+		t.Error("SetTransformer() expected error for space with data")
+	}
+}
+
+func TestRouter_Locate(t *testing.T) {
+	s := newTestSpace(t, curve.Hilbert, 2, 4, "n1", "n2", "n3")
+	data, err := (&Balancer{space: s}).ExportRoutes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRouter(data, coordsTransform)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Epoch() != s.Epoch() {
+		t.Errorf("Router.Epoch() = %d, want %d", r.Epoch(), s.Epoch())
+	}
+	for _, d := range randomItems(200, 2, 4, 1) {
+		route, err := s.LocateData(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := r.Locate(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != route.Node.ID() {
+			t.Errorf("Router.Locate(%s) = %s, want %s", d.ID(), got, route.Node.ID())
+		}
+	}
+	if _, err := NewRouter(data, transform.KVTransform); err == nil {
+		t.Error("NewRouter() expected error for different transform function")
+	}
+}
+
+func TestRouter_LocateWrite(t *testing.T) {
+	s := newMigrationSpace(t)
+	cgs, err := shiftOptimizer(s.Clone())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.StartMigration(cgs, 15); err != nil {
+		t.Fatal(err)
+	}
+	rs, err := s.RoutingState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs.Pending) == 0 {
+		t.Fatal("RoutingState() has no pending ranges during migration")
+	}
+	data, err := rs.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRouter(data, coordsTransform)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var moving int
+	for x := uint64(0); x < 16; x++ {
+		for y := uint64(0); y < 16; y++ {
+			d := testItem{"d", 0, []uint64{x, y}}
+			route, err := s.LocateData(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := r.LocateWrite(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := nodeIDs(route.Write); !reflect.DeepEqual(got, want) {
+				t.Fatalf("Router.LocateWrite(%v) = %v, want %v", d.coords, got, want)
+			}
+			if len(got) > 1 {
+				moving++
+			}
+		}
+	}
+	if moving == 0 {
+		t.Fatal("no cells are written to both nodes")
+	}
+}
@@ -0,0 +1,273 @@
+package balancer
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// maxRouteHistory is the number of previous routing tables kept by the space
+// to check routing decisions made with older epochs.
+const maxRouteHistory = 128
+
+// Route describes the placement of the data item.
+//
+// Node - node which holds the cell of the data item, reads go to it.
+//
+// Write - nodes which must receive writes to the cell. While the cell is being moved by
+// the migration, it contains both the old and the new node, otherwise only Node. The slice
+// is shared with the routing table and must not be modified.
+//
+// CellID - identifier of the cell of the data item.
+//
+// Epoch - epoch of the routing table used to locate the cell.
+type Route struct {
+	Node   Node
+	Write  []Node
+	CellID uint64
+	Epoch  uint64
+}
+
+// RouteStatus describes whether the routing decision made at some epoch is still valid.
+//
+// Valid - the cell is still held by the same node.
+//
+// Epoch - current epoch of the space.
+//
+// Node - node which currently holds the cell.
+//
+// Previous - node which held the cell at the checked epoch, nil if the epoch
+// is too old and is not kept in the history of the space.
+type RouteStatus struct {
+	Valid    bool
+	Epoch    uint64
+	Node     Node
+	Previous Node
+}
+
+// routingTable is an immutable snapshot of cell group ranges. It is swapped atomically
+// on every change of cell groups, so data items can be located without locking the space.
+// Ranges are sorted by their beginnings and searched with binary search.
+type routingTable struct {
+	epoch  uint64
+	mins   []uint64
+	maxs   []uint64
+	nodes  []Node
+	groups []*CellGroup
+	byID   map[string]*CellGroup
+	// writes are nodes which receive writes to cells of the ranges.
+	writes [][]Node
+	// pending are ranges of cells being moved to the nodes, writes to them go to both nodes.
+	pmins   []uint64
+	pmaxs   []uint64
+	pending []Node
+	pwrites [][]Node
+}
+
+// segment is a range of cells routed to the cell group.
+type segment struct {
+	r  Range
+	cg *CellGroup
+}
+
+// newRoutingTable builds routing table from the ranges of cell groups.
+// Groups with empty ranges are skipped in ranges, but can be found by node ID.
+func newRoutingTable(cgs []*CellGroup) *routingTable {
+	segs := make([]segment, 0, len(cgs))
+	for iter := range cgs {
+		segs = append(segs, segment{r: cgs[iter].Range(), cg: cgs[iter]})
+	}
+	return newSegmentsTable(segs, cgs)
+}
+
+// newSegmentsTable builds routing table from segments, a group may hold several of them.
+// Segments must not overlap, empty segments are skipped.
+func newSegmentsTable(segs []segment, cgs []*CellGroup) *routingTable {
+	es := make([]segment, 0, len(segs))
+	for iter := range segs {
+		if segs[iter].r.Min >= segs[iter].r.Max {
+			continue
+		}
+		es = append(es, segs[iter])
+	}
+	sort.Slice(es, func(i, j int) bool {
+		return es[i].r.Min < es[j].r.Min
+	})
+	rt := &routingTable{
+		mins:   make([]uint64, len(es)),
+		maxs:   make([]uint64, len(es)),
+		nodes:  make([]Node, len(es)),
+		groups: make([]*CellGroup, len(es)),
+		writes: make([][]Node, len(es)),
+		byID:   make(map[string]*CellGroup, len(cgs)),
+	}
+	for iter := range cgs {
+		rt.byID[cgs[iter].ID()] = cgs[iter]
+	}
+	for iter := range es {
+		rt.mins[iter] = es[iter].r.Min
+		rt.maxs[iter] = es[iter].r.Max
+		rt.nodes[iter] = es[iter].cg.Node()
+		rt.groups[iter] = es[iter].cg
+		rt.writes[iter] = rt.nodes[iter : iter+1 : iter+1]
+	}
+	return rt
+}
+
+// lookup returns the node which holds the cell.
+func (rt *routingTable) lookup(cID uint64) (Node, bool) {
+	i, ok := rt.search(cID)
+	if !ok {
+		return nil, false
+	}
+	return rt.nodes[i], true
+}
+
+// writeNodes returns nodes which must receive writes to the cell from the i-th range.
+// Slices are shared by all routes and must not be modified.
+func (rt *routingTable) writeNodes(i int, cID uint64) []Node {
+	if j, ok := searchRanges(rt.pmins, rt.pmaxs, cID); ok {
+		return rt.pwrites[j]
+	}
+	return rt.writes[i]
+}
+
+// setPending sets ranges of cells which are being moved to new nodes. Pending ranges are
+// split by the ranges of the table, so every part has the single old node. Parts which are
+// already held by the new node are skipped.
+func (rt *routingTable) setPending(segs []segment) {
+	rt.pmins, rt.pmaxs, rt.pending, rt.pwrites = nil, nil, nil, nil
+	for _, seg := range segs {
+		n := seg.cg.Node()
+		for pos := seg.r.Min; pos < seg.r.Max; {
+			i, ok := rt.search(pos)
+			if !ok {
+				break
+			}
+			end := rt.maxs[i]
+			if end > seg.r.Max {
+				end = seg.r.Max
+			}
+			if rt.nodes[i].ID() != n.ID() {
+				rt.pmins = append(rt.pmins, pos)
+				rt.pmaxs = append(rt.pmaxs, end)
+				rt.pending = append(rt.pending, n)
+				rt.pwrites = append(rt.pwrites, []Node{rt.nodes[i], n})
+			}
+			pos = end
+		}
+	}
+}
+
+// lookupGroup returns the cell group which holds the cell.
+func (rt *routingTable) lookupGroup(cID uint64) (*CellGroup, bool) {
+	i, ok := rt.search(cID)
+	if !ok {
+		return nil, false
+	}
+	return rt.groups[i], true
+}
+
+// search returns the index of the range containing the cell.
+func (rt *routingTable) search(cID uint64) (int, bool) {
+	return searchRanges(rt.mins, rt.maxs, cID)
+}
+
+// searchRanges returns the index of the range [mins[i], maxs[i]) containing the cell.
+// Ranges must be sorted and must not overlap.
+func searchRanges(mins, maxs []uint64, cID uint64) (int, bool) {
+	i, j := 0, len(mins)
+	for i < j {
+		h := int(uint(i+j) >> 1)
+		if mins[h] <= cID {
+			i = h + 1
+		} else {
+			j = h
+		}
+	}
+	if i == 0 || cID >= maxs[i-1] {
+		return 0, false
+	}
+	return i - 1, true
+}
+
+// updateRoutes rebuilds routing table from current cell groups and increments the epoch.
+// It must be called under the lock of the space.
+func (s *Space) updateRoutes() {
+	s.epoch++
+	var rt *routingTable
+	if s.migration != nil {
+		rt = newSegmentsTable(s.migration.segments(), s.cgs)
+		rt.setPending(s.migration.pending())
+	} else {
+		rt = newRoutingTable(s.cgs)
+	}
+	rt.epoch = s.epoch
+	s.routes.Store(rt)
+	s.history = append(s.history, rt)
+	if len(s.history) > maxRouteHistory {
+		s.history = append(s.history[:0], s.history[len(s.history)-maxRouteHistory:]...)
+	}
+	for _, ch := range s.watchers {
+		select {
+		case ch <- rt.epoch:
+		default:
+		}
+	}
+}
+
+// Watch returns the channel which receives the epoch every time the routing table changes,
+// and the function which stops watching. Notifications are not queued, if the receiver
+// is slow it gets only the latest one of several changes.
+func (s *Space) Watch() (<-chan uint64, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch := make(chan uint64, 1)
+	s.watchers = append(s.watchers, ch)
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for iter := range s.watchers {
+			if s.watchers[iter] == ch {
+				s.watchers = append(s.watchers[:iter], s.watchers[iter+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (s *Space) routingTable() *routingTable {
+	rt, _ := s.routes.Load().(*routingTable)
+	if rt == nil {
+		return &routingTable{byID: map[string]*CellGroup{}}
+	}
+	return rt
+}
+
+// Epoch returns current epoch of the space. Epoch is incremented every time cell groups
+// or nodes of the space change.
+func (s *Space) Epoch() uint64 {
+	return s.routingTable().epoch
+}
+
+// CheckRoute checks whether the cell located at the given epoch is still held by the same node.
+func (s *Space) CheckRoute(cID, epoch uint64) (RouteStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rt := s.routingTable()
+	if epoch > rt.epoch {
+		return RouteStatus{}, errors.Errorf("epoch(%d) is greater than current epoch(%d)", epoch, rt.epoch)
+	}
+	res := RouteStatus{
+		Epoch: rt.epoch,
+	}
+	res.Node, _ = rt.lookup(cID)
+	for iter := len(s.history) - 1; iter >= 0; iter-- {
+		if s.history[iter].epoch == epoch {
+			res.Previous, _ = s.history[iter].lookup(cID)
+			break
+		}
+	}
+	res.Valid = res.Node != nil && res.Previous != nil && res.Node.ID() == res.Previous.ID()
+	return res, nil
+}
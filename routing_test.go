@@ -0,0 +1,197 @@
+package balancer
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+func Test_routingTable_lookup(t *testing.T) {
+	n1 := testNode{id: "n1"}
+	n2 := testNode{id: "n2"}
+	n3 := testNode{id: "n3"}
+	cg := func(n Node, min, max uint64) *CellGroup {
+		res := NewCellGroup(n)
+		if err := res.SetRange(min, max); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	rt := newRoutingTable([]*CellGroup{
+		cg(n3, 20, 30),
+		cg(n1, 0, 10),
+		cg(testNode{id: "empty"}, 0, 0),
+		cg(n2, 10, 15),
+	})
+	tests := []struct {
+		name   string
+		cID    uint64
+		want   string
+		wantOk bool
+	}{
+		{"first cell", 0, "n1", true},
+		{"last cell of first range", 9, "n1", true},
+		{"first cell of second range", 10, "n2", true},
+		{"gap", 17, "", false},
+		{"last cell", 29, "n3", true},
+		{"after last range", 30, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := rt.lookup(tt.cID)
+			if ok != tt.wantOk {
+				t.Fatalf("lookup() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && got.ID() != tt.want {
+				t.Errorf("lookup() = %v, want %v", got.ID(), tt.want)
+			}
+		})
+	}
+	if allocs := testing.AllocsPerRun(100, func() { rt.lookup(12) }); allocs != 0 {
+		t.Errorf("lookup() allocations = %v, want 0", allocs)
+	}
+}
+
+func TestSpace_LocateDataConcurrent(t *testing.T) {
+	s := newTestSpace(t, curve.Hilbert, 2, 6, "n1", "n2", "n3")
+	items := randomItems(1000, 2, 6, 2)
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for _, d := range items {
+			if _, err := s.AddData(d); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for _, d := range items {
+			if _, err := s.LocateData(d); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	before := len(s.Cells())
+	for _, d := range randomItems(1000, 2, 6, 3) {
+		if _, err := s.LocateData(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if after := len(s.Cells()); after != before {
+		t.Errorf("LocateData() created %d cells", after-before)
+	}
+}
+
+func benchmarkSpace(b *testing.B, nodes int) *Space {
+	ids := make([]string, nodes)
+	for iter := range ids {
+		ids[iter] = fmt.Sprintf("n%d", iter)
+	}
+	return newTestSpace(b, curve.Hilbert, 2, 16, ids...)
+}
+
+var benchmarkNodes = []int{10, 100, 10000}
+
+func BenchmarkSpace_findCellGroup(b *testing.B) {
+	for _, nodes := range benchmarkNodes {
+		b.Run(fmt.Sprintf("nodes=%d", nodes), func(b *testing.B) {
+			s := benchmarkSpace(b, nodes)
+			total := s.TotalCells()
+			b.ResetTimer()
+			for iter := 0; iter < b.N; iter++ {
+				if _, ok := s.findCellGroup(uint64(iter) % total); !ok {
+					b.Fatal("cell group not found")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSpace_GetNode(b *testing.B) {
+	for _, nodes := range benchmarkNodes {
+		b.Run(fmt.Sprintf("nodes=%d", nodes), func(b *testing.B) {
+			s := benchmarkSpace(b, nodes)
+			b.ResetTimer()
+			for iter := 0; iter < b.N; iter++ {
+				if _, ok := s.GetNode(fmt.Sprintf("n%d", iter%nodes)); !ok {
+					b.Fatal("node not found")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSpace_LocateData(b *testing.B) {
+	for _, nodes := range benchmarkNodes {
+		b.Run(fmt.Sprintf("nodes=%d", nodes), func(b *testing.B) {
+			s := benchmarkSpace(b, nodes)
+			items := randomItems(1024, 2, 16, 1)
+			b.ResetTimer()
+			for iter := 0; iter < b.N; iter++ {
+				if _, err := s.LocateData(items[iter%len(items)]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestSpace_CheckRoute(t *testing.T) {
+	s := newTestSpace(t, curve.Hilbert, 2, 2, "n1", "n2")
+	r, err := s.LocateData(testItem{id: "d1", coords: []uint64{3, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Epoch != s.Epoch() {
+		t.Fatalf("LocateData() epoch = %d, want %d", r.Epoch, s.Epoch())
+	}
+	st, err := s.CheckRoute(r.CellID, r.Epoch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Valid {
+		t.Errorf("CheckRoute() = %+v, want valid route", st)
+	}
+
+	if err := s.AddNode(testNode{id: "n3"}); err != nil {
+		t.Fatal(err)
+	}
+	if s.Epoch() <= r.Epoch {
+		t.Fatalf("AddNode() did not increment epoch")
+	}
+	st, err = s.CheckRoute(r.CellID, r.Epoch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Valid || st.Epoch != s.Epoch() {
+		t.Errorf("CheckRoute() = %+v, want valid route at epoch %d", st, s.Epoch())
+	}
+
+	cgs := s.CellGroups()
+	moved := []*CellGroup{NewCellGroup(cgs[0].Node()), NewCellGroup(cgs[1].Node())}
+	if err := moved[0].SetRange(8, 16); err != nil {
+		t.Fatal(err)
+	}
+	if err := moved[1].SetRange(0, 8); err != nil {
+		t.Fatal(err)
+	}
+	s.SetGroups(moved)
+	st, err = s.CheckRoute(r.CellID, r.Epoch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Valid || st.Previous.ID() != r.Node.ID() || st.Node.ID() == r.Node.ID() {
+		t.Errorf("CheckRoute() = %+v, want invalid route moved from %s", st, r.Node.ID())
+	}
+
+	if _, err := s.CheckRoute(r.CellID, s.Epoch()+1); err == nil {
+		t.Error("CheckRoute() expected error for future epoch")
+	}
+}
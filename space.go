@@ -4,12 +4,21 @@ import (
 	"math"
 	"sort"
 	"sync"
+	"sync/atomic"
 
 	"github.com/pkg/errors"
 
 	"github.com/visheratin/balancer/curve"
 )
 
+var (
+	// ErrMigrationInProgress is returned when nodes or cell groups of the space are changed
+	// while the migration is in progress.
+	ErrMigrationInProgress = errors.New("migration is in progress")
+	// ErrNodeNotFound is returned when the node is not present in the space.
+	ErrNodeNotFound = errors.New("node not found")
+)
+
 type SpaceInterface interface {
 }
 
@@ -19,7 +28,19 @@ type Space struct {
 	cgs   []*CellGroup
 	sfc   curve.Curve
 	tf    TransformFunc
-	load  uint64
+	// tfID is the identity of the transform set by SetTransformer.
+	tfID string
+	load uint64
+	// routes holds *routingTable used by LocateData.
+	routes   atomic.Value
+	epoch    uint64
+	history  []*routingTable
+	watchers []chan uint64
+	// migration is the staged transition to the new partition, routing follows its
+	// current step while it is in progress.
+	migration *Migration
+	// pins are sorted non-overlapping restrictions of cell placement.
+	pins []Pin
 }
 
 func NewSpace(sfc curve.Curve, tf TransformFunc, nodes []Node) (*Space, error) {
@@ -36,7 +57,7 @@ func NewSpace(sfc curve.Curve, tf TransformFunc, nodes []Node) (*Space, error) {
 			return nil, err
 		}
 	}
-	l := sfc.Length()
+	l := sfc.Length() + 1
 	r, err := splitCells(len(nodes), l)
 	if err != nil {
 		return nil, err
@@ -44,6 +65,7 @@ func NewSpace(sfc curve.Curve, tf TransformFunc, nodes []Node) (*Space, error) {
 	for i := range s.cgs {
 		s.cgs[i].cRange = r[i]
 	}
+	s.updateRoutes()
 	return &s, nil
 }
 
@@ -53,21 +75,19 @@ func splitCells(n int, l uint64) ([]Range, error) {
 	}
 
 	s := float64(l) / float64(n)
-	var c float64
-	i := 0
 	res := make([]Range, n)
-	if n == 0 {
-		return res, nil
-	}
-	for c < float64(l) {
-		nc := c + s
+	var min uint64
+	for i := 0; i < n; i++ {
+		max := uint64(math.Ceil(s * float64(i+1)))
+		if i == n-1 || max > l {
+			max = l
+		}
 		res[i] = Range{
-			Min: uint64(math.Ceil(c)),
-			Max: uint64(math.Ceil(nc)),
+			Min: min,
+			Max: max,
+			Len: max - min,
 		}
-		res[i].Len = res[i].Max - res[i].Min
-		i++
-		c = nc
+		min = max
 	}
 	return res, nil
 }
@@ -123,11 +143,125 @@ func (s *Space) TotalCapacity() (cap float64) {
 	return
 }
 
-// SetGroups replace groups in the space.
+// SetGroups replace groups in the space. Groups may be built on the clone of the space,
+// cells of the space are bound to the groups by their ranges. Cells outside of all ranges
+// are bound to the group which holds the cell with the same ID.
+// Migration in progress is discarded.
 func (s *Space) SetGroups(groups []*CellGroup) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.migration != nil {
+		s.migration.aborted = true
+		s.migration = nil
+	}
 	s.cgs = groups
+	s.updateRoutes()
+	s.bindCells()
+}
+
+// bindCells rebuilds cells and loads of cell groups from the cells of the space.
+// It must be called under the lock of the space after the routing table is updated.
+func (s *Space) bindCells() {
+	held := map[uint64]*CellGroup{}
+	for _, cg := range s.cgs {
+		cg.mu.Lock()
+		for id := range cg.cells {
+			held[id] = cg
+		}
+		cg.cells = map[uint64]*cell{}
+		cg.load = 0
+		cg.mu.Unlock()
+	}
+	rt := s.routingTable()
+	for id, c := range s.cells {
+		cg, ok := rt.lookupGroup(id)
+		if !ok {
+			cg, ok = held[id]
+		}
+		if !ok {
+			continue
+		}
+		// AddCell is not used because the cell may already point to the group.
+		cg.mu.Lock()
+		cg.cells[id] = c
+		cg.load += c.Load()
+		cg.mu.Unlock()
+		c.SetGroup(cg)
+	}
+}
+
+// bindCell moves the cell to the group which holds it in the routing table. It must be
+// called under the lock of the space after the routing table is updated.
+func (s *Space) bindCell(id uint64) {
+	c, ok := s.cells[id]
+	if !ok {
+		return
+	}
+	cg, ok := s.routingTable().lookupGroup(id)
+	if !ok || c.cg == cg {
+		return
+	}
+	cg.AddCell(c, true)
+}
+
+// Clone returns a deep copy of the space with its own cells and cell groups, so the clone
+// can be changed, e.g. by optimizers, without affecting the space. Nodes, the curve,
+// the transform and pins are shared. The clone starts at the epoch of the space without
+// its routing history and watchers. The clone of the space with migration in progress
+// gets the new partition of the migration.
+func (s *Space) Clone() *Space {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cgs := s.cgs
+	if s.migration != nil {
+		cgs = s.migration.target
+	}
+	res := &Space{
+		cells: make(map[uint64]*cell, len(s.cells)),
+		cgs:   make([]*CellGroup, len(cgs)),
+		sfc:   s.sfc,
+		tf:    s.tf,
+		tfID:  s.tfID,
+		load:  s.load,
+		epoch: s.epoch - 1,
+		pins:  s.pins,
+	}
+	groups := make(map[*CellGroup]*CellGroup, len(cgs))
+	for iter, cg := range cgs {
+		res.cgs[iter] = NewCellGroup(cg.Node())
+		res.cgs[iter].cRange = cg.Range()
+		groups[cg] = res.cgs[iter]
+	}
+	res.updateRoutes()
+	rt := res.routingTable()
+	for id, c := range s.cells {
+		c.mu.Lock()
+		nc := NewCell(id, nil, c.load)
+		cg, ok := groups[c.cg]
+		c.mu.Unlock()
+		if !ok || s.migration != nil {
+			cg, ok = rt.lookupGroup(id)
+		}
+		if ok {
+			cg.AddCell(nc, false)
+		}
+		res.cells[id] = nc
+	}
+	return res
+}
+
+// SetTransformer replaces the transform function of the space with the transformer, its
+// identity is exported in the routing state. It must be called before the space is used,
+// the transform can not be changed after data is added to the space.
+func (s *Space) SetTransformer(t Transformer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.cells) > 0 {
+		return errors.New("unable to change transform of the space with data")
+	}
+	s.tf = t.Transform
+	s.tfID = t.Identity()
+	return nil
 }
 
 // Len returns the number of CellGroups in the space.
@@ -137,6 +271,11 @@ func (s *Space) Len() int {
 	return len(s.cgs)
 }
 
+// SFC returns the curve of the space.
+func (s *Space) SFC() curve.Curve {
+	return s.sfc
+}
+
 // TotalCells returns maximum number of cells which could be located in space
 func (s *Space) TotalCells() uint64 {
 	s.mu.Lock()
@@ -155,14 +294,15 @@ func (s *Space) AddNode(n Node) error {
 }
 
 func (s *Space) addNode(n Node) error {
-	//TODO May be s.cgs should be map
-	for iter := range s.cgs {
-		if s.cgs[iter].ID() == n.ID() {
-			s.cgs[iter].SetNode(n)
-			return nil
-		}
+	if s.migration != nil {
+		return errors.Wrap(ErrMigrationInProgress, "unable to add node")
+	}
+	if cg, ok := s.routingTable().byID[n.ID()]; ok {
+		cg.SetNode(n)
+	} else {
+		s.cgs = append(s.cgs, NewCellGroup(n))
 	}
-	s.cgs = append(s.cgs, NewCellGroup(n))
+	s.updateRoutes()
 	return nil
 }
 
@@ -174,13 +314,11 @@ func (s *Space) GetNode(id string) (Node, bool) {
 }
 
 func (s *Space) getNode(id string) (Node, bool) {
-	//TODO May be s.cgs should be map
-	for iter := range s.cgs {
-		if s.cgs[iter].ID() == id {
-			return s.cgs[iter].Node(), true
-		}
+	cg, ok := s.routingTable().byID[id]
+	if !ok {
+		return nil, false
 	}
-	return nil, false
+	return cg.Node(), true
 }
 
 //func (s *Space) SetNodes(ns []Node) error {
@@ -209,67 +347,114 @@ func (s *Space) RemoveNode(id string) error {
 	return nil
 }
 func (s *Space) removeNode(id string) error {
+	if s.migration != nil {
+		return errors.Wrap(ErrMigrationInProgress, "unable to remove node")
+	}
 	for iter := range s.cgs {
 		if s.cgs[iter].ID() == id {
 			s.cgs = append(s.cgs[:iter], s.cgs[iter+1:]...)
+			s.updateRoutes()
 			return nil
 		}
 	}
-	return errors.Errorf("node(%s) not found", id)
+	return errors.Wrapf(ErrNodeNotFound, "node(%s)", id)
 }
 
 // AddData adds data item to the space.
 func (s *Space) AddData(d DataItem) (Node, error) {
+	r, err := s.AddDataRoute(d)
+	return r.Node, err
+}
+
+// AddDataRoute adds data item to the space and returns its route. Node of the route is
+// the node where the item is counted, Write contains both nodes while the cell of the item
+// is being moved by the migration.
+func (s *Space) AddDataRoute(d DataItem) (Route, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	return s.addData(d)
 }
 
-func (s *Space) addData(d DataItem) (Node, error) {
+func (s *Space) addData(d DataItem) (Route, error) {
 	if len(s.cgs) == 0 {
-		return nil, errors.New("no nodes in the cluster")
+		return Route{}, errors.New("no nodes in the cluster")
 	}
 	cID, err := s.cellID(d)
 	if err != nil {
-		return nil, err
+		return Route{}, err
 	}
 	if _, ok := s.cells[cID]; !ok {
 		cg, ok := s.findCellGroup(cID)
 		if !ok {
-			return nil, errors.Errorf("unable to bind cell to cell group (cID=%v  d=%s)", cID, d.ID())
+			return Route{}, errors.Errorf("unable to bind cell to cell group (cID=%v  d=%s)", cID, d.ID())
 		}
-		s.cells[cID] = NewCell(cID, cg, 0)
+		s.cells[cID] = NewCell(cID, nil, 0)
+		cg.AddCell(s.cells[cID], false)
 	}
 	if err = s.cells[cID].add(d); err != nil {
-		return nil, err
+		return Route{}, err
 	}
 	s.load += d.Size()
-	return s.cells[cID].cg.Node(), nil
+	return s.cellRoute(s.routingTable(), s.cells[cID]), nil
 }
 
-// LocateData returns node for the data item.
-func (s *Space) LocateData(d DataItem) (Node, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	return s.locateData(d)
+// cellRoute returns the route of the populated cell. It must be called under the lock of
+// the space, so the routing table matches the binding of the cell.
+func (s *Space) cellRoute(rt *routingTable, c *cell) Route {
+	n := c.cg.Node()
+	res := Route{
+		Node:   n,
+		CellID: c.id,
+		Epoch:  rt.epoch,
+	}
+	if i, ok := rt.search(c.id); ok {
+		res.Write = rt.writeNodes(i, c.id)
+	} else {
+		res.Write = []Node{n}
+	}
+	return res
 }
 
-func (s *Space) locateData(d DataItem) (Node, error) {
-	if len(s.cgs) == 0 {
-		return nil, errors.New("no nodes in the cluster")
+// LocateData returns the route of the data item.
+// It does not acquire the lock of the space and reads the routing table which is
+// rebuilt on every change of cell groups, so reads do not contend with writers.
+func (s *Space) LocateData(d DataItem) (Route, error) {
+	rt := s.routingTable()
+	if len(rt.nodes) == 0 {
+		return Route{}, errors.New("no nodes in the cluster")
 	}
 	cID, err := s.cellID(d)
 	if err != nil {
-		return nil, err
+		return Route{}, err
 	}
-	if _, ok := s.cells[cID]; !ok {
-		cg, ok := s.findCellGroup(cID)
-		if !ok {
-			return nil, errors.Errorf("unable to bind cell to cell group (cID=%v  d=%s)", cID, d.ID())
+	i, ok := rt.search(cID)
+	if !ok {
+		return Route{}, errors.Errorf("unable to find cell group for cell (cID=%v  d=%s)", cID, d.ID())
+	}
+	return Route{
+		Node:   rt.nodes[i],
+		Write:  rt.writeNodes(i, cID),
+		CellID: cID,
+		Epoch:  rt.epoch,
+	}, nil
+}
+
+// LocateRange returns nodes which hold cells from the range [min, max).
+// Nodes are ordered by the beginning of their first range overlapping [min, max).
+func (s *Space) LocateRange(min, max uint64) ([]Node, error) {
+	if min >= max {
+		return nil, errors.Errorf("min(%d) should be less then max(%d)", min, max)
+	}
+	rt := s.routingTable()
+	var res []Node
+	seen := map[string]bool{}
+	for iter := range rt.mins {
+		if rt.mins[iter] < max && min < rt.maxs[iter] && !seen[rt.nodes[iter].ID()] {
+			seen[rt.nodes[iter].ID()] = true
+			res = append(res, rt.nodes[iter])
 		}
-		s.cells[cID] = NewCell(cID, cg, 0)
 	}
-	return s.cells[cID].cg.Node(), nil
+	return res, nil
 }
 
 //cellID calculates the id of cell in space based on transform function and space filling curve.
@@ -290,13 +475,11 @@ func (s *Space) cellID(d DataItem) (uint64, error) {
 	return cID, nil
 }
 
+// findCellGroup returns the cell group which holds the cell. It uses the index of
+// the routing table, so ranges of cell groups changed by CellGroup.SetRange are taken
+// into account after the next SetGroups.
 func (s *Space) findCellGroup(cID uint64) (cg *CellGroup, ok bool) {
-	for iter := range s.cgs {
-		if s.cgs[iter].InRange(cID) {
-			return s.cgs[iter], true
-		}
-	}
-	return nil, false
+	return s.routingTable().lookupGroup(cID)
 }
 
 func (s *Space) Nodes() []Node {
@@ -3,6 +3,8 @@ package balancer
 import (
 	"reflect"
 	"testing"
+
+	"github.com/visheratin/balancer/curve"
 )
 
 func Test_splitCells(t *testing.T) {
@@ -82,3 +84,124 @@ func Test_splitCells(t *testing.T) {
 		})
 	}
 }
+
+// shiftOptimizer moves the border between two cell groups to the middle of the first range,
+// mutating groups and cells of the space like optimizers of the optimizer package do.
+func shiftOptimizer(s *Space) ([]*CellGroup, error) {
+	cgs := s.CellGroups()
+	r := cgs[0].Range()
+	mid := (r.Min + r.Max) / 2
+	if err := cgs[0].SetRange(r.Min, mid); err != nil {
+		return nil, err
+	}
+	if err := cgs[1].SetRange(mid, cgs[1].Range().Max); err != nil {
+		return nil, err
+	}
+	for _, c := range s.Cells() {
+		if cgs[1].InRange(c.ID()) {
+			cgs[1].AddCell(c, true)
+		}
+	}
+	return cgs, nil
+}
+
+func TestSpace_Clone(t *testing.T) {
+	s := newTestSpace(t, curve.Hilbert, 2, 4, "n1", "n2")
+	for iter := uint64(0); iter < 16; iter++ {
+		if _, err := s.AddData(testItem{"d", 10, []uint64{iter, iter}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	state, err := s.RoutingState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := s.Clone()
+	if c.Epoch() != s.Epoch() || c.TotalLoad() != s.TotalLoad() || len(c.Cells()) != len(s.Cells()) {
+		t.Fatal("clone differs from the space")
+	}
+	cgs, err := shiftOptimizer(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.AddData(testItem{"d", 5, []uint64{1, 1}}); err != nil {
+		t.Fatal(err)
+	}
+	after, err := s.RoutingState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(state, after) {
+		t.Fatal("changes of the clone affected routing of the space")
+	}
+	if s.TotalLoad() != 160 {
+		t.Fatalf("changes of the clone affected load of the space: %d", s.TotalLoad())
+	}
+	var groupsLoad uint64
+	for _, cg := range s.CellGroups() {
+		groupsLoad += cg.TotalLoad()
+		for id, cell := range cg.Cells() {
+			if !cg.InRange(id) || cell.cg != cg {
+				t.Fatalf("cell %d is bound to the wrong group", id)
+			}
+		}
+	}
+	if groupsLoad != 160 {
+		t.Fatalf("loads of groups of the space changed: %d", groupsLoad)
+	}
+
+	s.SetGroups(cgs)
+	groupsLoad = 0
+	for _, cg := range s.CellGroups() {
+		groupsLoad += cg.TotalLoad()
+		for id, cell := range cg.Cells() {
+			if cell != s.cells[id] {
+				t.Fatalf("group holds cell %d of the clone", id)
+			}
+			if !cg.InRange(id) || cell.cg != cg {
+				t.Fatalf("cell %d is bound to the wrong group", id)
+			}
+		}
+	}
+	if groupsLoad != s.TotalLoad() {
+		t.Fatalf("load of groups %d does not match load of the space %d", groupsLoad, s.TotalLoad())
+	}
+	n, err := s.AddData(testItem{"d", 1, []uint64{15, 15}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	route, err := s.LocateData(testItem{"d", 1, []uint64{15, 15}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.ID() != route.Node.ID() {
+		t.Fatalf("data added to %s, but routed to %s", n.ID(), route.Node.ID())
+	}
+}
+
+func TestBalancer_Optimize(t *testing.T) {
+	b := &Balancer{
+		space: newTestSpace(t, curve.Hilbert, 2, 4, "n1", "n2"),
+		of:    shiftOptimizer,
+	}
+	for iter := uint64(0); iter < 16; iter++ {
+		if _, err := b.AddData(testItem{"d", 10, []uint64{iter, 0}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	epoch := b.Epoch()
+	before := b.Space().CellGroups()[0].Range()
+	cgs, err := b.Optimize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Epoch() != epoch || b.Space().CellGroups()[0].Range() != before {
+		t.Fatal("Optimize changed the space")
+	}
+	if err := b.Apply(cgs); err != nil {
+		t.Fatal(err)
+	}
+	if b.Epoch() == epoch || b.Space().CellGroups()[0].Range() == before {
+		t.Fatal("Apply did not change the space")
+	}
+}
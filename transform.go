@@ -3,3 +3,12 @@ package balancer
 import "github.com/visheratin/balancer/curve"
 
 type TransformFunc func(values []interface{}, sfc curve.Curve) ([]uint64, error)
+
+// Transformer is the transform with parameters, e.g. fitted boundaries or random
+// projections. Identity returns the name of the transform and the hash of its parameters,
+// it identifies the transform in the exported routing state, so routers reject states
+// exported with differently configured transforms.
+type Transformer interface {
+	Transform(values []interface{}, sfc curve.Curve) ([]uint64, error)
+	Identity() string
+}
@@ -0,0 +1,111 @@
+package transform
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"time"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+// DimensionFunc maps a single value into the coordinate of one dimension of the curve.
+// dimSize is the maximum coordinate value in the dimension.
+type DimensionFunc func(value interface{}, dimSize uint64) (uint64, error)
+
+// NewCompositeTransform assembles transform function from per-dimension functions.
+// The i-th value of the data item is mapped by the i-th function. The number of functions
+// must match the number of curve dimensions.
+func NewCompositeTransform(sfc curve.Curve, dims ...DimensionFunc) (func(values []interface{}, sfc curve.Curve) ([]uint64, error), error) {
+	if uint64(len(dims)) != sfc.Dimensions() {
+		return nil, fmt.Errorf("number of dimension functions(%d) must match number of curve dimensions(%d)", len(dims), sfc.Dimensions())
+	}
+	for iter := range dims {
+		if dims[iter] == nil {
+			return nil, fmt.Errorf("dimension function %d is nil", iter)
+		}
+	}
+	return func(values []interface{}, sfc curve.Curve) ([]uint64, error) {
+		if len(values) != len(dims) || sfc.Dimensions() != uint64(len(dims)) {
+			return nil, fmt.Errorf("number of dimensions must be %d", len(dims))
+		}
+		dimSize := sfc.DimensionSize()
+		res := make([]uint64, len(dims))
+		for iter := range dims {
+			c, err := dims[iter](values[iter], dimSize)
+			if err != nil {
+				return nil, fmt.Errorf("dimension %d: %v", iter, err)
+			}
+			res[iter] = c
+		}
+		return res, nil
+	}, nil
+}
+
+// HashDimension maps string value into the dimension using FNV-1a hash. Equal strings
+// are placed into the same coordinate, but locality of similar strings is not preserved.
+// The number of coordinates is a power of 2, so the hash is masked by dimSize, which also
+// covers the full 64-bit dimension.
+func HashDimension() DimensionFunc {
+	return func(value interface{}, dimSize uint64) (uint64, error) {
+		s, ok := value.(string)
+		if !ok {
+			return 0, errors.New("value must be string")
+		}
+		h := fnv.New64a()
+		h.Write([]byte(s))
+		return h.Sum64() & dimSize, nil
+	}
+}
+
+// StringDimension maps string value into the dimension preserving lexicographical order,
+// so strings with common prefix are placed close to each other.
+func StringDimension() DimensionFunc {
+	return func(value interface{}, dimSize uint64) (uint64, error) {
+		s, ok := value.(string)
+		if !ok {
+			return 0, errors.New("value must be string")
+		}
+		var v uint64
+		for iter := 0; iter < 8; iter++ {
+			v <<= 8
+			if iter < len(s) {
+				v |= uint64(s[iter])
+			}
+		}
+		return v >> (64 - uint(bits.Len64(dimSize))), nil
+	}
+}
+
+// RangeDimension maps numeric value from [min, max] interval into the dimension linearly.
+// Values outside the interval are clamped to its borders. Error is returned if min is not
+// less than max.
+func RangeDimension(min, max float64) (DimensionFunc, error) {
+	if !(min < max) {
+		return nil, fmt.Errorf("min(%v) must be less than max(%v)", min, max)
+	}
+	return func(value interface{}, dimSize uint64) (uint64, error) {
+		v, ok := toFloat64(value)
+		if !ok {
+			return 0, errors.New("value must be numeric")
+		}
+		return quantize((v-min)/(max-min), dimSize), nil
+	}, nil
+}
+
+// TimeDimension maps time.Time value from [from, to] interval into the dimension linearly.
+// Values outside the interval are clamped to its borders. Error is returned if from is not
+// before to.
+func TimeDimension(from, to time.Time) (DimensionFunc, error) {
+	if !from.Before(to) {
+		return nil, errors.New("start of the interval must be before its end")
+	}
+	return func(value interface{}, dimSize uint64) (uint64, error) {
+		t, ok := value.(time.Time)
+		if !ok {
+			return 0, errors.New("value must be time.Time")
+		}
+		return quantize(float64(t.Sub(from))/float64(to.Sub(from)), dimSize), nil
+	}, nil
+}
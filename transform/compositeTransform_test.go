@@ -0,0 +1,122 @@
+package transform
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+func TestCompositeTransform(t *testing.T) {
+	from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	sfc, _ := curve.NewCurve(curve.Hilbert, 4, 4)
+	rd, err := RangeDimension(0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	td, err := TimeDimension(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tf, err := NewCompositeTransform(sfc, HashDimension(), StringDimension(), rd, td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		values  []interface{}
+		want    []uint64
+		wantErr bool
+	}{
+		{
+			name:    "tenant and timestamp",
+			values:  valuesConv("tenant", "a", 50, from.Add(30*time.Minute)),
+			want:    []uint64{5, 6, 7, 7},
+			wantErr: false,
+		},
+		{
+			name:    "clamped",
+			values:  valuesConv("tenant", "zz", 150.0, to.Add(time.Hour)),
+			want:    []uint64{5, 7, 15, 15},
+			wantErr: false,
+		},
+		{
+			name:    "wrong time type",
+			values:  valuesConv("tenant", "a", 50, "12:00"),
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "wrong number of values",
+			values:  valuesConv("tenant", "a", 50),
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tf(tt.values, sfc)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CompositeTransform() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompositeTransform() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCompositeTransform(t *testing.T) {
+	sfc, _ := curve.NewCurve(curve.Hilbert, 2, 4)
+	if _, err := NewCompositeTransform(sfc, HashDimension()); err == nil {
+		t.Error("NewCompositeTransform() expected error for mismatched number of dimensions")
+	}
+	if _, err := NewCompositeTransform(sfc, HashDimension(), nil); err == nil {
+		t.Error("NewCompositeTransform() expected error for nil dimension function")
+	}
+}
+
+func TestDimensionConfig(t *testing.T) {
+	from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := RangeDimension(100, 0); err == nil {
+		t.Error("RangeDimension() expected error for min greater than max")
+	}
+	if _, err := RangeDimension(1, 1); err == nil {
+		t.Error("RangeDimension() expected error for empty interval")
+	}
+	if _, err := RangeDimension(math.NaN(), 1); err == nil {
+		t.Error("RangeDimension() expected error for NaN border")
+	}
+	if _, err := TimeDimension(from, from); err == nil {
+		t.Error("TimeDimension() expected error for empty interval")
+	}
+	if _, err := TimeDimension(from.Add(time.Hour), from); err == nil {
+		t.Error("TimeDimension() expected error for reversed interval")
+	}
+}
+
+func TestHashDimensionFullWidth(t *testing.T) {
+	sfc, err := curve.NewCurve(curve.Hilbert, 1, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tf, err := NewCompositeTransform(sfc, HashDimension())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := tf(valuesConv("a"), sfc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := tf(valuesConv("b"), sfc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a[0] == b[0] {
+		t.Errorf("HashDimension() placed different strings into the same coordinate %d", a[0])
+	}
+}
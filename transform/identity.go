@@ -0,0 +1,36 @@
+package transform
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+// Named is the transform function with the explicit identity. It is used for transforms
+// whose configuration can not be identified by the function, e.g. the ones built by
+// NewCompositeTransform.
+type Named struct {
+	ID   string
+	Func func(values []interface{}, sfc curve.Curve) ([]uint64, error)
+}
+
+// Transform maps values with the wrapped function.
+func (n Named) Transform(values []interface{}, sfc curve.Curve) ([]uint64, error) {
+	return n.Func(values, sfc)
+}
+
+// Identity returns the explicit identity of the transform.
+func (n Named) Identity() string {
+	return n.ID
+}
+
+// identity returns the name of the transform followed by the FNV-1a hash of its
+// parameters, e.g. "quantile:af63bd4c8601b7df".
+func identity(name string, params ...interface{}) string {
+	h := fnv.New64a()
+	for _, p := range params {
+		fmt.Fprintf(h, "%v;", p)
+	}
+	return fmt.Sprintf("%s:%016x", name, h.Sum64())
+}
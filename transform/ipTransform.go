@@ -0,0 +1,116 @@
+package transform
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+// IPv4Transform maps IPv4 address, passed as net.IP or string, into the coordinates of
+// the curve. The most significant bits of the address become the code of the cell, so
+// addresses sharing a prefix are placed into the contiguous range of cells.
+func IPv4Transform(values []interface{}, sfc curve.Curve) ([]uint64, error) {
+	ip, err := ipValue(values)
+	if err != nil {
+		return nil, err
+	}
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return nil, errors.New("value must be IPv4 address")
+	}
+	return sfc.Decode(ipv4Code(ip4, sfc))
+}
+
+// IPv6Transform maps IPv6 address, passed as net.IP or string, into the coordinates of
+// the curve. The most significant bits of the address become the code of the cell, so
+// addresses sharing a prefix are placed into the contiguous range of cells. IPv4 addresses,
+// including IPv4-mapped IPv6 ones, are placed into the cells of the reserved ::/8 prefix,
+// their 32 bits follow the first 8 zero bits of the code, so they keep prefix locality.
+func IPv6Transform(values []interface{}, sfc curve.Curve) ([]uint64, error) {
+	ip, err := ipValue(values)
+	if err != nil {
+		return nil, err
+	}
+	return sfc.Decode(ipv6Code(ip, sfc))
+}
+
+// CIDRRange returns the range of cell codes [min, max) which contains all addresses of the
+// network. Cells are computed in the same way as IPv4Transform for IPv4 networks and as
+// IPv6Transform for IPv6 networks. Error is returned if the network ends at the last code
+// of the 64-bit curve, because max does not fit into uint64.
+func CIDRRange(n *net.IPNet, sfc curve.Curve) (min, max uint64, err error) {
+	return cidrRange(n, sfc, false)
+}
+
+// IPv6CIDRRange returns the range of cell codes [min, max) which contains all addresses of
+// the network. Cells are computed in the same way as IPv6Transform for all networks, so it
+// is used for spaces which hold both IPv4 and IPv6 addresses.
+func IPv6CIDRRange(n *net.IPNet, sfc curve.Curve) (min, max uint64, err error) {
+	return cidrRange(n, sfc, true)
+}
+
+func cidrRange(n *net.IPNet, sfc curve.Curve, v6 bool) (min, max uint64, err error) {
+	if n == nil {
+		return 0, 0, errors.New("network must not be nil")
+	}
+	first := n.IP.Mask(n.Mask)
+	if first == nil {
+		return 0, 0, errors.New("network mask does not match address length")
+	}
+	last := make(net.IP, len(first))
+	for iter := range first {
+		last[iter] = first[iter] | ^n.Mask[iter]
+	}
+	if len(first) == net.IPv4len && !v6 {
+		min, max = ipv4Code(first, sfc), ipv4Code(last, sfc)
+	} else {
+		min, max = ipv6Code(first, sfc), ipv6Code(last, sfc)
+	}
+	if max == ^uint64(0) {
+		return 0, 0, errors.New("network ends at the last code of the curve, range end overflows")
+	}
+	return min, max + 1, nil
+}
+
+func ipValue(values []interface{}) (net.IP, error) {
+	if len(values) != 1 {
+		return nil, errors.New("number of values must be 1")
+	}
+	var ip net.IP
+	switch v := values[0].(type) {
+	case net.IP:
+		ip = v
+	case string:
+		ip = net.ParseIP(v)
+	default:
+		return nil, errors.New("value must be net.IP or string")
+	}
+	if ip.To16() == nil {
+		return nil, errors.New("invalid IP address")
+	}
+	return ip, nil
+}
+
+// ipv4Code aligns 32 bits of the address with the bits of the curve code.
+func ipv4Code(ip net.IP, sfc curve.Curve) uint64 {
+	v := uint64(binary.BigEndian.Uint32(ip.To4()))
+	total := sfc.Dimensions() * sfc.Bits()
+	if total >= 32 {
+		return v << (total - 32)
+	}
+	return v >> (32 - total)
+}
+
+// ipv6Code takes the most significant bits of the address which fit into the curve code.
+// IPv4 address is placed after the first 8 bits, inside the reserved ::/8 prefix.
+func ipv6Code(ip net.IP, sfc curve.Curve) uint64 {
+	var v uint64
+	if ip4 := ip.To4(); ip4 != nil {
+		v = uint64(binary.BigEndian.Uint32(ip4)) << 24
+	} else {
+		v = binary.BigEndian.Uint64(ip.To16()[:8])
+	}
+	return v >> (64 - sfc.Dimensions()*sfc.Bits())
+}
@@ -0,0 +1,173 @@
+package transform
+
+import (
+	"net"
+	"testing"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+func TestIPTransformCIDR(t *testing.T) {
+	type args struct {
+		cidr  string
+		cType curve.CurveType
+		dims  uint64
+		bits  uint64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		inside  []string
+		outside []string
+	}{
+		{
+			name:    "IPv4 Hilbert 2x16",
+			args:    args{"10.1.0.0/16", curve.Hilbert, 2, 16},
+			inside:  []string{"10.1.0.0", "10.1.127.3", "10.1.255.255"},
+			outside: []string{"10.0.255.255", "10.2.0.0", "192.168.1.1"},
+		},
+		{
+			name:    "IPv4 Morton 4x4",
+			args:    args{"172.16.0.0/12", curve.Morton, 4, 4},
+			inside:  []string{"172.16.0.1", "172.31.255.255"},
+			outside: []string{"172.32.0.0", "172.15.255.255"},
+		},
+		{
+			name:    "IPv4 Hilbert 3x16",
+			args:    args{"192.168.0.0/24", curve.Hilbert, 3, 16},
+			inside:  []string{"192.168.0.0", "192.168.0.255"},
+			outside: []string{"192.168.1.0"},
+		},
+		{
+			name:    "IPv6 Hilbert 2x16",
+			args:    args{"2001:db8::/32", curve.Hilbert, 2, 16},
+			inside:  []string{"2001:db8::1", "2001:db8:ffff::"},
+			outside: []string{"2001:db9::", "2001:db7:ffff::"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sfc, err := curve.NewCurve(tt.args.cType, tt.args.dims, tt.args.bits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, n, err := net.ParseCIDR(tt.args.cidr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			min, max, err := CIDRRange(n, sfc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tf := IPv4Transform
+			if n.IP.To4() == nil {
+				tf = IPv6Transform
+			}
+			code := func(ip string) uint64 {
+				coords, err := tf(valuesConv(ip), sfc)
+				if err != nil {
+					t.Fatal(err)
+				}
+				c, err := sfc.Encode(coords)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return c
+			}
+			for _, ip := range tt.inside {
+				if c := code(ip); c < min || c >= max {
+					t.Errorf("code of %s = %d, want in [%d, %d)", ip, c, min, max)
+				}
+			}
+			for _, ip := range tt.outside {
+				if c := code(ip); c >= min && c < max {
+					t.Errorf("code of %s = %d, want outside [%d, %d)", ip, c, min, max)
+				}
+			}
+		})
+	}
+}
+
+func TestIPv6TransformIPv4(t *testing.T) {
+	sfc, _ := curve.NewCurve(curve.Hilbert, 2, 8)
+	code := func(ip string) uint64 {
+		coords, err := IPv6Transform(valuesConv(ip), sfc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := sfc.Encode(coords)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	if a, b := code("10.0.0.1"), code("192.168.5.7"); a == b {
+		t.Errorf("IPv4 addresses are placed into the same cell %d", a)
+	}
+	if a, b := code("10.0.0.1"), code("::ffff:10.0.0.1"); a != b {
+		t.Errorf("IPv4-mapped address code = %d, want %d", b, a)
+	}
+	if c := code("2001:db8::1"); c < 1<<8 {
+		t.Errorf("IPv6 address code = %d, want outside of ::/8", c)
+	}
+
+	// 8 bits of the code are taken by the ::/8 prefix, so the curve must have 24 more bits.
+	sfc, _ = curve.NewCurve(curve.Hilbert, 2, 16)
+	_, n, err := net.ParseCIDR("10.1.0.0/16")
+	if err != nil {
+		t.Fatal(err)
+	}
+	min, max, err := IPv6CIDRRange(n, sfc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ip := range []string{"10.1.0.0", "10.1.255.255", "::ffff:10.1.2.3"} {
+		if c := code(ip); c < min || c >= max {
+			t.Errorf("code of %s = %d, want in [%d, %d)", ip, c, min, max)
+		}
+	}
+	for _, ip := range []string{"10.0.255.255", "11.1.0.0", "192.168.1.1", "2001:db8::1"} {
+		if c := code(ip); c >= min && c < max {
+			t.Errorf("code of %s = %d, want outside [%d, %d)", ip, c, min, max)
+		}
+	}
+}
+
+func TestCIDRRangeOverflow(t *testing.T) {
+	sfc, _ := curve.NewCurve(curve.Hilbert, 2, 32)
+	_, n, err := net.ParseCIDR("::/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := CIDRRange(n, sfc); err == nil {
+		t.Error("CIDRRange() expected error for network ending at the last code")
+	}
+	_, n, err = net.ParseCIDR("::/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if min, max, err := CIDRRange(n, sfc); err != nil || min != 0 || max != 1<<63 {
+		t.Errorf("CIDRRange() = [%d, %d), %v, want [0, %d)", min, max, err, uint64(1)<<63)
+	}
+}
+
+func TestIPTransformErrors(t *testing.T) {
+	sfc, _ := curve.NewCurve(curve.Hilbert, 2, 16)
+	tests := []struct {
+		name   string
+		tf     func(values []interface{}, sfc curve.Curve) ([]uint64, error)
+		values []interface{}
+	}{
+		{"IPv6 address in IPv4 transform", IPv4Transform, valuesConv("2001:db8::1")},
+		{"invalid address", IPv6Transform, valuesConv("10.0.0")},
+		{"wrong type", IPv4Transform, valuesConv(42)},
+		{"too many values", IPv4Transform, valuesConv("10.0.0.1", "10.0.0.2")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.tf(tt.values, sfc); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
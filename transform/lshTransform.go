@@ -0,0 +1,105 @@
+package transform
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+// LSHTransform maps embedding vectors into the coordinates of the curve using random
+// projection locality-sensitive hashing. Every dimension of the curve is a projection
+// of the normalized vector onto the random direction, so vectors with small angular
+// distance are placed into close cells.
+//
+// Projections are generated from the seed, so transforms created with equal parameters
+// produce equal coordinates.
+type LSHTransform struct {
+	inputDims   int
+	projections [][]float64
+}
+
+// NewLSHTransform creates transform for vectors of inputDims length projected onto
+// outputDims random directions. outputDims must match the number of curve dimensions.
+func NewLSHTransform(inputDims, outputDims int, seed int64) (*LSHTransform, error) {
+	if inputDims <= 0 || outputDims <= 0 {
+		return nil, errors.New("number of input and output dimensions must be greater than 0")
+	}
+	rnd := rand.New(rand.NewSource(seed))
+	ps := make([][]float64, outputDims)
+	for iter := range ps {
+		p := make([]float64, inputDims)
+		var norm float64
+		for norm == 0 {
+			for i := range p {
+				p[i] = rnd.NormFloat64()
+				norm += p[i] * p[i]
+			}
+		}
+		norm = math.Sqrt(norm)
+		for i := range p {
+			p[i] /= norm
+		}
+		ps[iter] = p
+	}
+	return &LSHTransform{
+		inputDims:   inputDims,
+		projections: ps,
+	}, nil
+}
+
+// Identity returns the name of the transform and the hash of its projections.
+func (lt *LSHTransform) Identity() string {
+	return identity("lsh", lt.inputDims, lt.projections)
+}
+
+// Transform maps vector passed as []float64 or []float32 into the coordinates of the curve.
+// The method value can be used as a transform function of the balancer.
+func (lt *LSHTransform) Transform(values []interface{}, sfc curve.Curve) ([]uint64, error) {
+	if len(values) != 1 {
+		return nil, errors.New("number of values must be 1")
+	}
+	if sfc.Dimensions() != uint64(len(lt.projections)) {
+		return nil, fmt.Errorf("number of dimensions must be %d", len(lt.projections))
+	}
+	var vec []float64
+	switch v := values[0].(type) {
+	case []float64:
+		vec = v
+	case []float32:
+		vec = make([]float64, len(v))
+		for iter := range v {
+			vec[iter] = float64(v[iter])
+		}
+	default:
+		return nil, errors.New("value must be []float64 or []float32")
+	}
+	if len(vec) != lt.inputDims {
+		return nil, fmt.Errorf("vector length must be %d", lt.inputDims)
+	}
+	var norm float64
+	for iter := range vec {
+		norm += vec[iter] * vec[iter]
+	}
+	norm = math.Sqrt(norm)
+	dimSize := sfc.DimensionSize()
+	scale := math.Sqrt(float64(lt.inputDims))
+	res := make([]uint64, len(lt.projections))
+	for iter, p := range lt.projections {
+		if norm == 0 {
+			res[iter] = quantize(0.5, dimSize)
+			continue
+		}
+		var dot float64
+		for i := range p {
+			dot += p[i] * vec[i]
+		}
+		// Projection of the unit vector onto random direction is approximately normally
+		// distributed with variance 1/inputDims, so the normal CDF spreads it evenly.
+		x := dot / norm * scale
+		res[iter] = quantize(0.5*(1+math.Erf(x/math.Sqrt2)), dimSize)
+	}
+	return res, nil
+}
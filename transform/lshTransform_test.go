@@ -0,0 +1,76 @@
+package transform
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+func TestLSHTransform(t *testing.T) {
+	sfc, _ := curve.NewCurve(curve.Hilbert, 2, 8)
+	lt, err := NewLSHTransform(64, 2, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rnd := rand.New(rand.NewSource(1))
+	base := make([]float64, 64)
+	near := make([]float32, 64)
+	far := make([]float64, 64)
+	for iter := range base {
+		base[iter] = rnd.NormFloat64()
+		near[iter] = float32(base[iter] + rnd.NormFloat64()*0.01)
+		far[iter] = -base[iter]
+	}
+	coords := func(v interface{}) []uint64 {
+		res, err := lt.Transform(valuesConv(v), sfc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	dist := func(a, b []uint64) uint64 {
+		var res uint64
+		for iter := range a {
+			if a[iter] > b[iter] {
+				res += a[iter] - b[iter]
+			} else {
+				res += b[iter] - a[iter]
+			}
+		}
+		return res
+	}
+	b, n, f := coords(base), coords(near), coords(far)
+	if dist(b, n) >= dist(b, f) {
+		t.Errorf("distance to similar vector %d is not less than to opposite vector %d", dist(b, n), dist(b, f))
+	}
+
+	same, _ := NewLSHTransform(64, 2, 42)
+	got, err := same.Transform(valuesConv(base), sfc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, b) {
+		t.Errorf("LSHTransform.Transform() with same seed = %v, want %v", got, b)
+	}
+
+	if _, err := lt.Transform(valuesConv(make([]float64, 3)), sfc); err == nil {
+		t.Error("LSHTransform.Transform() expected error for wrong vector length")
+	}
+	if _, err := lt.Transform(valuesConv("vector"), sfc); err == nil {
+		t.Error("LSHTransform.Transform() expected error for wrong value type")
+	}
+}
+
+func TestLSHTransform_Identity(t *testing.T) {
+	a, _ := NewLSHTransform(16, 2, 1)
+	b, _ := NewLSHTransform(16, 2, 1)
+	c, _ := NewLSHTransform(16, 2, 2)
+	if a.Identity() != b.Identity() {
+		t.Errorf("transforms with equal parameters have different identities %s and %s", a.Identity(), b.Identity())
+	}
+	if a.Identity() == c.Identity() {
+		t.Errorf("transforms with different seeds have equal identity %s", a.Identity())
+	}
+}
@@ -0,0 +1,106 @@
+package transform
+
+import (
+	"errors"
+	"math"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+// Projection describes how latitude and longitude are projected onto the plane
+// before quantization.
+type Projection int
+
+const (
+	// Linear quantizes latitude and longitude as is, same as SpaceTransform.
+	Linear Projection = iota
+	// WebMercator uses the spherical Web Mercator projection. Latitudes beyond
+	// mercatorMaxLat are clamped to the borders of the space.
+	WebMercator
+	// EqualArea uses the Lambert cylindrical equal-area projection, so every cell
+	// covers the same area on the surface of the Earth.
+	EqualArea
+)
+
+func (p Projection) String() string {
+	switch p {
+	case Linear:
+		return "Linear"
+	case WebMercator:
+		return "WebMercator"
+	case EqualArea:
+		return "EqualArea"
+	}
+	return ""
+}
+
+// mercatorMaxLat is the latitude at which Web Mercator projection becomes a square.
+const mercatorMaxLat = 85.05112877980659
+
+// Latitude maps latitude into the [0, 1] interval the same way the transform of the
+// projection does before quantization.
+func (p Projection) Latitude(lat float64) float64 {
+	switch p {
+	case WebMercator:
+		lat = math.Max(math.Min(lat, mercatorMaxLat), -mercatorMaxLat)
+		y := math.Log(math.Tan(math.Pi/4 + lat*math.Pi/360))
+		return (y + math.Pi) / (2 * math.Pi)
+	case EqualArea:
+		return (math.Sin(lat*math.Pi/180) + 1) / 2
+	}
+	return (lat + latStep) / (latStep * 2)
+}
+
+// NewGeoTransform returns transform function that maps latitude and longitude into
+// the coordinates of the 2-dimensional curve using specified projection.
+func NewGeoTransform(p Projection) (func(values []interface{}, sfc curve.Curve) ([]uint64, error), error) {
+	switch p {
+	case Linear:
+		return SpaceTransform, nil
+	case WebMercator:
+		return MercatorTransform, nil
+	case EqualArea:
+		return EqualAreaTransform, nil
+	default:
+		return nil, errors.New("unknown projection")
+	}
+}
+
+// MercatorTransform maps latitude and longitude into the coordinates of the curve
+// using Web Mercator projection.
+func MercatorTransform(values []interface{}, sfc curve.Curve) ([]uint64, error) {
+	return geoTransform(values, sfc, WebMercator.Latitude)
+}
+
+// EqualAreaTransform maps latitude and longitude into the coordinates of the curve
+// using Lambert cylindrical equal-area projection.
+func EqualAreaTransform(values []interface{}, sfc curve.Curve) ([]uint64, error) {
+	return geoTransform(values, sfc, EqualArea.Latitude)
+}
+
+// geoTransform quantizes longitude linearly and latitude via projection function,
+// which maps latitude into [0, 1] interval.
+func geoTransform(values []interface{}, sfc curve.Curve, project func(lat float64) float64) ([]uint64, error) {
+	dimSize := sfc.DimensionSize()
+	if len(values) != 2 || sfc.Dimensions() != 2 {
+		return nil, errors.New("number of dimensions must be 2")
+	}
+	res := make([]uint64, 2)
+	lat, ok := values[0].(float64)
+	if !ok {
+		return nil, errors.New("first value must be float64 latitude")
+	}
+	if lat < -latStep || lat > latStep {
+		return nil, errors.New("latitude must be in [-90, 90] interval")
+	}
+	res[0] = quantize(project(lat), dimSize)
+	lon, ok := values[1].(float64)
+	if !ok {
+		return nil, errors.New("second value must be float64 longitude")
+	}
+	if lon < -lonStep || lon > lonStep {
+		return nil, errors.New("longitude must be in [-180, 180] interval")
+	}
+	res[1] = quantize((lon+lonStep)/(lonStep*2), dimSize)
+	return res, nil
+}
@@ -0,0 +1,125 @@
+package transform
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+func TestGeoTransform(t *testing.T) {
+	type args struct {
+		values []interface{}
+		p      Projection
+		bits   uint64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []uint64
+		wantErr bool
+	}{
+		{
+			name: "Linear center",
+			args: args{
+				values: []interface{}{0.0, 0.0},
+				p:      Linear,
+				bits:   8,
+			},
+			want:    []uint64{127, 127},
+			wantErr: false,
+		},
+		{
+			name: "WebMercator center",
+			args: args{
+				values: []interface{}{0.0, 0.0},
+				p:      WebMercator,
+				bits:   8,
+			},
+			want:    []uint64{127, 127},
+			wantErr: false,
+		},
+		{
+			name: "WebMercator 60 latitude",
+			args: args{
+				values: []interface{}{60.0, 90.0},
+				p:      WebMercator,
+				bits:   8,
+			},
+			want:    []uint64{180, 191},
+			wantErr: false,
+		},
+		{
+			name: "WebMercator pole clamped",
+			args: args{
+				values: []interface{}{90.0, 180.0},
+				p:      WebMercator,
+				bits:   8,
+			},
+			want:    []uint64{255, 255},
+			wantErr: false,
+		},
+		{
+			name: "EqualArea 30 latitude",
+			args: args{
+				values: []interface{}{30.0, -180.0},
+				p:      EqualArea,
+				bits:   8,
+			},
+			want:    []uint64{191, 0},
+			wantErr: false,
+		},
+		{
+			name: "EqualArea south pole",
+			args: args{
+				values: []interface{}{-90.0, 0.0},
+				p:      EqualArea,
+				bits:   8,
+			},
+			want:    []uint64{0, 127},
+			wantErr: false,
+		},
+		{
+			name: "latitude out of range",
+			args: args{
+				values: []interface{}{91.0, 0.0},
+				p:      EqualArea,
+				bits:   8,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "wrong value type",
+			args: args{
+				values: []interface{}{"0", 0.0},
+				p:      WebMercator,
+				bits:   8,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sfc, err := curve.NewCurve(curve.Hilbert, 2, tt.args.bits)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			tf, err := NewGeoTransform(tt.args.p)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			got, err := tf(tt.args.values, sfc)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GeoTransform() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GeoTransform() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package transform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+// QuantileTransform maps numeric values through the empirical cumulative distribution
+// function of every dimension before quantization, so skewed data is spread evenly
+// across the cells of the curve.
+//
+// Boundaries contains sorted quantile boundaries for every dimension. Transform is
+// fully described by boundaries, so it can be serialized and reloaded to keep
+// routing stable across restarts.
+type QuantileTransform struct {
+	Boundaries [][]float64 `json:"boundaries"`
+}
+
+// FitQuantileTransform computes quantile boundaries from the samples of DataItem.Values().
+// Every sample must contain the same number of numeric values. The number of quantiles
+// defines the resolution of the resulting distribution function.
+func FitQuantileTransform(samples [][]interface{}, quantiles int) (*QuantileTransform, error) {
+	if len(samples) == 0 {
+		return nil, errors.New("samples must not be empty")
+	}
+	if quantiles < 1 {
+		return nil, errors.New("number of quantiles must be greater than 0")
+	}
+	dims := len(samples[0])
+	if dims == 0 {
+		return nil, errors.New("samples must contain values")
+	}
+	cols := make([][]float64, dims)
+	for i := range cols {
+		cols[i] = make([]float64, len(samples))
+	}
+	for i, sample := range samples {
+		if len(sample) != dims {
+			return nil, fmt.Errorf("sample %d has %d values, expected %d", i, len(sample), dims)
+		}
+		for d, v := range sample {
+			f, ok := toFloat64(v)
+			if !ok {
+				return nil, fmt.Errorf("value %d of sample %d is not numeric", d, i)
+			}
+			cols[d][i] = f
+		}
+	}
+	qt := &QuantileTransform{
+		Boundaries: make([][]float64, dims),
+	}
+	for d, col := range cols {
+		sort.Float64s(col)
+		b := make([]float64, 0, quantiles+1)
+		for q := 0; q <= quantiles; q++ {
+			v := col[int(math.Round(float64(q)/float64(quantiles)*float64(len(col)-1)))]
+			if len(b) > 0 && b[len(b)-1] == v {
+				continue
+			}
+			b = append(b, v)
+		}
+		qt.Boundaries[d] = b
+	}
+	return qt, nil
+}
+
+// LoadQuantileTransform reads transform previously written by QuantileTransform.Save.
+func LoadQuantileTransform(r io.Reader) (*QuantileTransform, error) {
+	qt := &QuantileTransform{}
+	if err := json.NewDecoder(r).Decode(qt); err != nil {
+		return nil, err
+	}
+	for d, b := range qt.Boundaries {
+		if len(b) == 0 {
+			return nil, fmt.Errorf("boundaries of dimension %d are empty", d)
+		}
+		if !sort.Float64sAreSorted(b) {
+			return nil, fmt.Errorf("boundaries of dimension %d are not sorted", d)
+		}
+	}
+	return qt, nil
+}
+
+// Save writes transform to w, so it can be loaded with LoadQuantileTransform.
+func (qt *QuantileTransform) Save(w io.Writer) error {
+	return json.NewEncoder(w).Encode(qt)
+}
+
+// Identity returns the name of the transform and the hash of its boundaries.
+func (qt *QuantileTransform) Identity() string {
+	return identity("quantile", qt.Boundaries)
+}
+
+// Transform maps values into the coordinates of the curve. The method value can be used
+// as a transform function of the balancer.
+func (qt *QuantileTransform) Transform(values []interface{}, sfc curve.Curve) ([]uint64, error) {
+	if len(values) != len(qt.Boundaries) || sfc.Dimensions() != uint64(len(qt.Boundaries)) {
+		return nil, fmt.Errorf("number of dimensions must be %d", len(qt.Boundaries))
+	}
+	dimSize := sfc.DimensionSize()
+	res := make([]uint64, len(values))
+	for d, v := range values {
+		f, ok := toFloat64(v)
+		if !ok {
+			return nil, fmt.Errorf("value %d is not numeric", d)
+		}
+		res[d] = quantize(qt.cdf(d, f), dimSize)
+	}
+	return res, nil
+}
+
+// cdf returns the value of the empirical distribution function of dimension d,
+// linearly interpolated between quantile boundaries.
+func (qt *QuantileTransform) cdf(d int, v float64) float64 {
+	b := qt.Boundaries[d]
+	if len(b) == 1 {
+		if v < b[0] {
+			return 0
+		}
+		return 1
+	}
+	i := sort.SearchFloat64s(b, v)
+	if i == 0 {
+		return 0
+	}
+	if i == len(b) {
+		return 1
+	}
+	frac := (v - b[i-1]) / (b[i] - b[i-1])
+	return (float64(i-1) + frac) / float64(len(b)-1)
+}
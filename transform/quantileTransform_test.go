@@ -0,0 +1,99 @@
+package transform
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+func quadraticSamples(n int) [][]interface{} {
+	res := make([][]interface{}, n)
+	for iter := range res {
+		res[iter] = valuesConv(iter, float64(iter*iter))
+	}
+	return res
+}
+
+func TestQuantileTransform(t *testing.T) {
+	qt, err := FitQuantileTransform(quadraticSamples(101), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantBounds := [][]float64{
+		{0, 25, 50, 75, 100},
+		{0, 625, 2500, 5625, 10000},
+	}
+	if !reflect.DeepEqual(qt.Boundaries, wantBounds) {
+		t.Fatalf("FitQuantileTransform() boundaries = %v, want %v", qt.Boundaries, wantBounds)
+	}
+	tests := []struct {
+		name    string
+		values  []interface{}
+		want    []uint64
+		wantErr bool
+	}{
+		{
+			name:    "median",
+			values:  valuesConv(50, 2500.0),
+			want:    []uint64{127, 127},
+			wantErr: false,
+		},
+		{
+			name:    "interpolated",
+			values:  valuesConv(25, 1250.0),
+			want:    []uint64{63, 85},
+			wantErr: false,
+		},
+		{
+			name:    "out of sample",
+			values:  valuesConv(-10, 20000.0),
+			want:    []uint64{0, 255},
+			wantErr: false,
+		},
+		{
+			name:    "not numeric",
+			values:  valuesConv("key", 1.0),
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "wrong number of values",
+			values:  valuesConv(1.0),
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	sfc, _ := curve.NewCurve(curve.Hilbert, 2, 8)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := qt.Transform(tt.values, sfc)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("QuantileTransform.Transform() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("QuantileTransform.Transform() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuantileTransformSaveLoad(t *testing.T) {
+	qt, err := FitQuantileTransform(quadraticSamples(1000), 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := qt.Save(buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadQuantileTransform(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(qt, loaded) {
+		t.Errorf("LoadQuantileTransform() = %v, want %v", loaded, qt)
+	}
+}
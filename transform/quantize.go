@@ -0,0 +1,13 @@
+package transform
+
+// quantize maps the fraction f from the [0, 1] interval onto the coordinate
+// in [0, dimSize]. Values outside the interval are clamped to its borders.
+func quantize(f float64, dimSize uint64) uint64 {
+	if f <= 0 {
+		return 0
+	}
+	if f >= 1 {
+		return dimSize
+	}
+	return uint64(f * float64(dimSize))
+}
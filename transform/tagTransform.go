@@ -0,0 +1,208 @@
+package transform
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+// tagName is the name of the struct tag describing the mapping of the field into the
+// dimension of the curve.
+const tagName = "balancer"
+
+// TagTransform extracts values from the fields of a struct and maps them into the
+// coordinates of the curve according to the struct tags. Numeric fields are tagged
+// with dimension and value range, e.g. `balancer:"dim=0,min=-90,max=90"`, string fields
+// are hashed and tagged with dimension only, e.g. `balancer:"dim=1,hash"`.
+type TagTransform struct {
+	typ    reflect.Type
+	fields []tagField
+}
+
+type tagField struct {
+	name  string
+	index []int
+	hash  bool
+	min   float64
+	max   float64
+}
+
+// NewTagTransform builds transform from the struct tags of the sample type. Sample must
+// be a struct or a pointer to a struct. Every dimension of the curve must be tagged
+// exactly once.
+func NewTagTransform(sample interface{}, sfc curve.Curve) (*TagTransform, error) {
+	typ := reflect.TypeOf(sample)
+	if typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, errors.New("sample must be a struct or a pointer to a struct")
+	}
+	dims := int(sfc.Dimensions())
+	fields := make([]tagField, dims)
+	found := make([]bool, dims)
+	for iter := 0; iter < typ.NumField(); iter++ {
+		sf := typ.Field(iter)
+		tag, ok := sf.Tag.Lookup(tagName)
+		if !ok {
+			continue
+		}
+		if sf.PkgPath != "" {
+			return nil, fmt.Errorf("field %s is not exported", sf.Name)
+		}
+		dim, f, err := parseTag(sf, tag)
+		if err != nil {
+			return nil, err
+		}
+		if dim >= dims {
+			return nil, fmt.Errorf("field %s: dimension %d exceeds number of curve dimensions %d", sf.Name, dim, dims)
+		}
+		if found[dim] {
+			return nil, fmt.Errorf("field %s: dimension %d is already tagged by field %s", sf.Name, dim, fields[dim].name)
+		}
+		found[dim] = true
+		fields[dim] = f
+	}
+	for dim := range found {
+		if !found[dim] {
+			return nil, fmt.Errorf("dimension %d is not tagged", dim)
+		}
+	}
+	return &TagTransform{
+		typ:    typ,
+		fields: fields,
+	}, nil
+}
+
+func parseTag(sf reflect.StructField, tag string) (int, tagField, error) {
+	f := tagField{
+		name:  sf.Name,
+		index: sf.Index,
+	}
+	dim := -1
+	var hasMin, hasMax bool
+	for _, part := range strings.Split(tag, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		var err error
+		switch kv[0] {
+		case "dim":
+			if len(kv) != 2 {
+				return 0, f, fmt.Errorf("field %s: dim value is not set", sf.Name)
+			}
+			dim, err = strconv.Atoi(kv[1])
+			if err == nil && dim < 0 {
+				err = errors.New("dimension must not be negative")
+			}
+		case "min":
+			if len(kv) != 2 {
+				return 0, f, fmt.Errorf("field %s: min value is not set", sf.Name)
+			}
+			f.min, err = strconv.ParseFloat(kv[1], 64)
+			hasMin = true
+		case "max":
+			if len(kv) != 2 {
+				return 0, f, fmt.Errorf("field %s: max value is not set", sf.Name)
+			}
+			f.max, err = strconv.ParseFloat(kv[1], 64)
+			hasMax = true
+		case "hash":
+			f.hash = true
+		default:
+			err = fmt.Errorf("unknown option %q", kv[0])
+		}
+		if err != nil {
+			return 0, f, fmt.Errorf("field %s: %v", sf.Name, err)
+		}
+	}
+	if dim < 0 {
+		return 0, f, fmt.Errorf("field %s: dim is not set", sf.Name)
+	}
+	if f.hash {
+		if sf.Type.Kind() != reflect.String {
+			return 0, f, fmt.Errorf("field %s: only string fields can be hashed", sf.Name)
+		}
+		return dim, f, nil
+	}
+	switch sf.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+	default:
+		return 0, f, fmt.Errorf("field %s: type %s is not numeric", sf.Name, sf.Type)
+	}
+	if !hasMin || !hasMax {
+		return 0, f, fmt.Errorf("field %s: min and max must be set for numeric field", sf.Name)
+	}
+	if f.min >= f.max {
+		return 0, f, fmt.Errorf("field %s: min(%v) must be less than max(%v)", sf.Name, f.min, f.max)
+	}
+	return dim, f, nil
+}
+
+// Values extracts values of tagged fields from v ordered by dimension. The result can be
+// returned from DataItem.Values().
+func (tt *TagTransform) Values(v interface{}) ([]interface{}, error) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return nil, errors.New("value must not be nil")
+	}
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, errors.New("value must not be nil")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Type() != tt.typ {
+		return nil, fmt.Errorf("value type must be %s", tt.typ)
+	}
+	res := make([]interface{}, len(tt.fields))
+	for dim, f := range tt.fields {
+		fv := rv.FieldByIndex(f.index)
+		switch fv.Kind() {
+		case reflect.String:
+			res[dim] = fv.String()
+		case reflect.Float32, reflect.Float64:
+			res[dim] = fv.Float()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			res[dim] = fv.Int()
+		default:
+			res[dim] = fv.Uint()
+		}
+	}
+	return res, nil
+}
+
+// Identity returns the name of the transform and the hash of the type and tagged fields.
+func (tt *TagTransform) Identity() string {
+	return identity("tag", tt.typ, tt.fields)
+}
+
+// Transform maps values extracted by TagTransform.Values into the coordinates of the curve.
+// The method value can be used as a transform function of the balancer.
+func (tt *TagTransform) Transform(values []interface{}, sfc curve.Curve) ([]uint64, error) {
+	if len(values) != len(tt.fields) || sfc.Dimensions() != uint64(len(tt.fields)) {
+		return nil, fmt.Errorf("number of dimensions must be %d", len(tt.fields))
+	}
+	dimSize := sfc.DimensionSize()
+	res := make([]uint64, len(values))
+	for dim, f := range tt.fields {
+		if f.hash {
+			s, ok := values[dim].(string)
+			if !ok {
+				return nil, fmt.Errorf("value of field %s must be string", f.name)
+			}
+			res[dim] = stringhash(s, dimSize)
+			continue
+		}
+		v, ok := toFloat64(values[dim])
+		if !ok {
+			return nil, fmt.Errorf("value of field %s must be numeric", f.name)
+		}
+		res[dim] = quantize((v-f.min)/(f.max-f.min), dimSize)
+	}
+	return res, nil
+}
@@ -0,0 +1,118 @@
+package transform
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+type taggedPoint struct {
+	ID     string
+	Lat    float64 `balancer:"dim=0,min=-90,max=90"`
+	Lon    float64 `balancer:"dim=1,min=-180,max=180"`
+	Tenant string  `balancer:"dim=2,hash"`
+}
+
+func TestNewTagTransform(t *testing.T) {
+	tests := []struct {
+		name    string
+		sample  interface{}
+		dims    uint64
+		wantErr bool
+	}{
+		{
+			name:    "struct",
+			sample:  taggedPoint{},
+			dims:    3,
+			wantErr: false,
+		},
+		{
+			name:    "pointer",
+			sample:  &taggedPoint{},
+			dims:    3,
+			wantErr: false,
+		},
+		{
+			name:    "untagged dimension",
+			sample:  taggedPoint{},
+			dims:    4,
+			wantErr: true,
+		},
+		{
+			name:    "dimension out of curve",
+			sample:  taggedPoint{},
+			dims:    2,
+			wantErr: true,
+		},
+		{
+			name: "duplicate dimension",
+			sample: struct {
+				A int `balancer:"dim=0,min=0,max=10"`
+				B int `balancer:"dim=0,min=0,max=10"`
+			}{},
+			dims:    1,
+			wantErr: true,
+		},
+		{
+			name: "missing range",
+			sample: struct {
+				A int `balancer:"dim=0,min=0"`
+			}{},
+			dims:    1,
+			wantErr: true,
+		},
+		{
+			name: "hashed number",
+			sample: struct {
+				A int `balancer:"dim=0,hash"`
+			}{},
+			dims:    1,
+			wantErr: true,
+		},
+		{
+			name:    "not a struct",
+			sample:  42,
+			dims:    1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sfc, _ := curve.NewCurve(curve.Morton, tt.dims, 4)
+			_, err := NewTagTransform(tt.sample, sfc)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewTagTransform() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTagTransform(t *testing.T) {
+	sfc, _ := curve.NewCurve(curve.Morton, 3, 4)
+	tt, err := NewTagTransform(taggedPoint{}, sfc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &taggedPoint{ID: "p1", Lat: 0, Lon: 180, Tenant: "key"}
+	values, err := tt.Values(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := valuesConv(0.0, 180.0, "key"); !reflect.DeepEqual(values, want) {
+		t.Errorf("TagTransform.Values() = %v, want %v", values, want)
+	}
+	got, err := tt.Transform(values, sfc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint64{7, 15, 14}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TagTransform.Transform() = %v, want %v", got, want)
+	}
+	if _, err := tt.Values(struct{}{}); err == nil {
+		t.Error("TagTransform.Values() expected error for value of another type")
+	}
+	if _, err := tt.Values(nil); err == nil {
+		t.Error("TagTransform.Values() expected error for nil value")
+	}
+}
@@ -0,0 +1,196 @@
+package balancer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PartitionError describes the problems found in the partition of the space into cell groups.
+//
+// Gaps - ranges of cells which do not belong to any cell group.
+//
+// Overlaps - ranges of cells which belong to more than one cell group.
+//
+// Excess - ranges of cell groups which lie beyond the last cell of the space.
+//
+// MisplacedCells - identifiers of cells whose ID is outside the range of their cell group.
+//
+// UnknownNodes - identifiers of nodes of cell groups which are not present in the space.
+//
+// MissingNodes - identifiers of nodes of the space which have no cell group.
+//
+// DuplicateNodes - identifiers of nodes which have more than one cell group.
+//
+// PinViolations - ranges of pinned cells held by nodes which are not allowed by pins.
+type PartitionError struct {
+	Gaps           []Range
+	Overlaps       []Range
+	Excess         []Range
+	MisplacedCells []uint64
+	UnknownNodes   []string
+	MissingNodes   []string
+	DuplicateNodes []string
+	PinViolations  []Range
+}
+
+func (e *PartitionError) Error() string {
+	var parts []string
+	if len(e.Gaps) > 0 {
+		parts = append(parts, fmt.Sprintf("gaps %s", rangesString(e.Gaps)))
+	}
+	if len(e.Overlaps) > 0 {
+		parts = append(parts, fmt.Sprintf("overlaps %s", rangesString(e.Overlaps)))
+	}
+	if len(e.Excess) > 0 {
+		parts = append(parts, fmt.Sprintf("ranges beyond the space %s", rangesString(e.Excess)))
+	}
+	if len(e.MisplacedCells) > 0 {
+		parts = append(parts, fmt.Sprintf("cells outside of group range %v", e.MisplacedCells))
+	}
+	if len(e.UnknownNodes) > 0 {
+		parts = append(parts, fmt.Sprintf("unknown nodes %v", e.UnknownNodes))
+	}
+	if len(e.MissingNodes) > 0 {
+		parts = append(parts, fmt.Sprintf("nodes without groups %v", e.MissingNodes))
+	}
+	if len(e.DuplicateNodes) > 0 {
+		parts = append(parts, fmt.Sprintf("nodes with several groups %v", e.DuplicateNodes))
+	}
+	if len(e.PinViolations) > 0 {
+		parts = append(parts, fmt.Sprintf("pinned cells on not allowed nodes %s", rangesString(e.PinViolations)))
+	}
+	return "invalid partition: " + strings.Join(parts, "; ")
+}
+
+func (e *PartitionError) empty() bool {
+	return len(e.Gaps) == 0 && len(e.Overlaps) == 0 && len(e.Excess) == 0 &&
+		len(e.MisplacedCells) == 0 && len(e.UnknownNodes) == 0 && len(e.MissingNodes) == 0 &&
+		len(e.DuplicateNodes) == 0 && len(e.PinViolations) == 0
+}
+
+func rangesString(rs []Range) string {
+	res := make([]string, len(rs))
+	for iter := range rs {
+		res[iter] = fmt.Sprintf("[%d, %d)", rs[iter].Min, rs[iter].Max)
+	}
+	return strings.Join(res, " ")
+}
+
+// ValidateGroups checks that ranges of cell groups cover all cells of the space
+// [0, TotalCells()) without gaps and overlaps, that cells of every group are inside
+// its range, that every node of the space has exactly one group, that every group belongs
+// to the node of the space and that pinned cells are held by allowed nodes.
+// It returns *PartitionError describing all found problems or nil if groups are valid.
+func (s *Space) ValidateGroups(cgs []*CellGroup) error {
+	s.mu.Lock()
+	known := make(map[string]bool, len(s.cgs))
+	ids := make([]string, len(s.cgs))
+	for iter := range s.cgs {
+		known[s.cgs[iter].ID()] = true
+		ids[iter] = s.cgs[iter].ID()
+	}
+	total := s.sfc.Length() + 1
+	pins := s.pins
+	s.mu.Unlock()
+
+	res := &PartitionError{}
+	rs := make([]Range, 0, len(cgs))
+	groups := make(map[string]int, len(cgs))
+	for _, cg := range cgs {
+		if !known[cg.ID()] {
+			res.UnknownNodes = append(res.UnknownNodes, cg.ID())
+		}
+		if groups[cg.ID()]++; groups[cg.ID()] == 2 {
+			res.DuplicateNodes = append(res.DuplicateNodes, cg.ID())
+		}
+		r := cg.Range()
+		for id := range cg.Cells() {
+			if id < r.Min || id >= r.Max {
+				res.MisplacedCells = append(res.MisplacedCells, id)
+			}
+		}
+		if r.Min < r.Max {
+			rs = append(rs, r)
+		}
+		res.PinViolations = append(res.PinViolations, pinViolations(pins, cg, r)...)
+	}
+	for _, id := range ids {
+		if groups[id] == 0 {
+			res.MissingNodes = append(res.MissingNodes, id)
+		}
+	}
+	sort.Strings(res.MissingNodes)
+	sort.Strings(res.DuplicateNodes)
+	sort.Slice(res.PinViolations, func(i, j int) bool {
+		return res.PinViolations[i].Min < res.PinViolations[j].Min
+	})
+	sort.Slice(res.MisplacedCells, func(i, j int) bool {
+		return res.MisplacedCells[i] < res.MisplacedCells[j]
+	})
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i].Min < rs[j].Min
+	})
+	var pos uint64
+	for _, r := range rs {
+		if r.Min > pos {
+			res.Gaps = appendRange(res.Gaps, pos, r.Min)
+		}
+		if r.Min < pos {
+			res.Overlaps = appendRange(res.Overlaps, r.Min, minUint64(pos, r.Max))
+		}
+		if r.Max > total {
+			res.Excess = appendRange(res.Excess, maxUint64(r.Min, total), r.Max)
+		}
+		pos = maxUint64(pos, r.Max)
+	}
+	if pos < total {
+		res.Gaps = appendRange(res.Gaps, pos, total)
+	}
+	if res.empty() {
+		return nil
+	}
+	return res
+}
+
+// pinViolations returns parts of the range of the group and its cells outside of the
+// range which are pinned to other nodes.
+func pinViolations(pins []Pin, cg *CellGroup, r Range) []Range {
+	var res []Range
+	for _, p := range pins {
+		if p.Range.Min < r.Max && r.Min < p.Range.Max && !p.Allows(cg.ID()) {
+			res = appendRange(res, maxUint64(p.Range.Min, r.Min), minUint64(p.Range.Max, r.Max))
+		}
+	}
+	for id := range cg.Cells() {
+		if id >= r.Min && id < r.Max {
+			continue
+		}
+		if p, ok := findPin(pins, id); ok && !p.Allows(cg.ID()) {
+			res = appendRange(res, id, id+1)
+		}
+	}
+	return res
+}
+
+func appendRange(rs []Range, min, max uint64) []Range {
+	return append(rs, Range{
+		Min: min,
+		Max: max,
+		Len: max - min,
+	})
+}
+
+func minUint64(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxUint64(a, b uint64) uint64 {
+	if a > b {
+		return a
+	}
+	return b
+}
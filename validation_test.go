@@ -0,0 +1,94 @@
+package balancer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/visheratin/balancer/curve"
+)
+
+func TestSpace_ValidateGroups(t *testing.T) {
+	s := newTestSpace(t, curve.Hilbert, 2, 2, "n1", "n2")
+	cg := func(id string, min, max uint64, cells ...uint64) *CellGroup {
+		res := NewCellGroup(testNode{id: id})
+		if err := res.SetRange(min, max); err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range cells {
+			res.AddCell(NewCell(c, nil, 1), false)
+		}
+		return res
+	}
+	tests := []struct {
+		name string
+		cgs  []*CellGroup
+		want *PartitionError
+	}{
+		{
+			name: "valid",
+			cgs:  []*CellGroup{cg("n2", 10, 16, 12), cg("n1", 0, 10, 0, 9)},
+			want: nil,
+		},
+		{
+			name: "valid with empty range",
+			cgs:  []*CellGroup{cg("n1", 0, 16), cg("n2", 16, 16)},
+			want: nil,
+		},
+		{
+			name: "gaps",
+			cgs:  []*CellGroup{cg("n1", 2, 8), cg("n2", 10, 14)},
+			want: &PartitionError{
+				Gaps: []Range{{0, 2, 2}, {8, 10, 2}, {14, 16, 2}},
+			},
+		},
+		{
+			name: "overlap and excess",
+			cgs:  []*CellGroup{cg("n1", 0, 10), cg("n2", 8, 17)},
+			want: &PartitionError{
+				Overlaps: []Range{{8, 10, 2}},
+				Excess:   []Range{{16, 17, 1}},
+			},
+		},
+		{
+			name: "misplaced cells and unknown nodes",
+			cgs:  []*CellGroup{cg("n1", 0, 8, 9), cg("n3", 8, 16, 3)},
+			want: &PartitionError{
+				MisplacedCells: []uint64{3, 9},
+				UnknownNodes:   []string{"n3"},
+				MissingNodes:   []string{"n2"},
+			},
+		},
+		{
+			name: "missing node",
+			cgs:  []*CellGroup{cg("n1", 0, 16)},
+			want: &PartitionError{
+				MissingNodes: []string{"n2"},
+			},
+		},
+		{
+			name: "duplicate node",
+			cgs:  []*CellGroup{cg("n1", 0, 8), cg("n2", 8, 12), cg("n1", 12, 16)},
+			want: &PartitionError{
+				DuplicateNodes: []string{"n1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.ValidateGroups(tt.cgs)
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("ValidateGroups() error = %v, want nil", err)
+				}
+				return
+			}
+			got, ok := err.(*PartitionError)
+			if !ok {
+				t.Fatalf("ValidateGroups() error = %v, want *PartitionError", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateGroups() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
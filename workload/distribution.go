@@ -0,0 +1,182 @@
+package workload
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Distribution creates a sampler of points with the given number of dimensions. All
+// randomness must come from rng, so the workload is reproducible. Samplers are created
+// for every workload, so one distribution can be used by several workloads.
+type Distribution func(rng *rand.Rand, dims int) (Sampler, error)
+
+// Sampler returns the point of the step-th item. Coordinates outside [0, 1] are clamped.
+type Sampler func(step int) []float64
+
+// maxResamples is the number of attempts to draw a Gaussian point inside [0, 1]
+// before it is clamped.
+const maxResamples = 8
+
+// Uniform distributes points uniformly over the space.
+func Uniform() Distribution {
+	return func(rng *rand.Rand, dims int) (Sampler, error) {
+		return func(int) []float64 {
+			p := make([]float64, dims)
+			for iter := range p {
+				p[iter] = rng.Float64()
+			}
+			return p
+		}, nil
+	}
+}
+
+// Hotspot is a Gaussian cluster of points.
+//
+// Weight - relative share of points in the hotspot.
+//
+// Drift - displacement of the center per generated item, it moves the hotspot over time.
+// Moving centers wrap around borders of the space.
+type Hotspot struct {
+	Center []float64
+	StdDev float64
+	Weight float64
+	Drift  []float64
+}
+
+// Hotspots distributes points between Gaussian hotspots proportionally to their weights.
+func Hotspots(hs ...Hotspot) Distribution {
+	return func(rng *rand.Rand, dims int) (Sampler, error) {
+		for iter, h := range hs {
+			if len(h.Center) != dims {
+				return nil, errors.Errorf("hotspot %d: center must have %d coordinates", iter, dims)
+			}
+			if h.Drift != nil && len(h.Drift) != dims {
+				return nil, errors.Errorf("hotspot %d: drift must have %d coordinates", iter, dims)
+			}
+		}
+		return hotspots(rng, hs)
+	}
+}
+
+// RandomHotspots distributes points between n hotspots of equal weight with random centers.
+func RandomHotspots(n int, stdDev float64) Distribution {
+	return MovingHotspots(n, stdDev, 0)
+}
+
+// MovingHotspots distributes points between n hotspots with random centers which move
+// in random directions by speed per generated item.
+func MovingHotspots(n int, stdDev, speed float64) Distribution {
+	return func(rng *rand.Rand, dims int) (Sampler, error) {
+		hs := make([]Hotspot, n)
+		for iter := range hs {
+			hs[iter] = Hotspot{
+				Center: make([]float64, dims),
+				StdDev: stdDev,
+				Weight: 1,
+			}
+			for d := range hs[iter].Center {
+				hs[iter].Center[d] = rng.Float64()
+			}
+			if speed == 0 {
+				continue
+			}
+			dir := make([]float64, dims)
+			var norm float64
+			for d := range dir {
+				dir[d] = rng.NormFloat64()
+				norm += dir[d] * dir[d]
+			}
+			norm = math.Sqrt(norm)
+			for d := range dir {
+				dir[d] *= speed / norm
+			}
+			hs[iter].Drift = dir
+		}
+		return hotspots(rng, hs)
+	}
+}
+
+func hotspots(rng *rand.Rand, hs []Hotspot) (Sampler, error) {
+	if len(hs) == 0 {
+		return nil, errors.New("at least one hotspot is required")
+	}
+	cum := make([]float64, len(hs))
+	var total float64
+	for iter, h := range hs {
+		if h.Weight < 0 || h.StdDev < 0 {
+			return nil, errors.Errorf("hotspot %d: weight and deviation must not be negative", iter)
+		}
+		total += h.Weight
+		cum[iter] = total
+	}
+	if total == 0 {
+		return nil, errors.New("total weight of hotspots must be positive")
+	}
+	return func(step int) []float64 {
+		h := hs[pick(rng, cum)]
+		p := make([]float64, len(h.Center))
+		for d := range p {
+			c := h.Center[d]
+			if h.Drift != nil {
+				c += h.Drift[d] * float64(step)
+				c -= math.Floor(c)
+			}
+			for attempt := 0; attempt < maxResamples; attempt++ {
+				p[d] = c + rng.NormFloat64()*h.StdDev
+				if p[d] >= 0 && p[d] <= 1 {
+					break
+				}
+			}
+		}
+		return p
+	}, nil
+}
+
+// pick returns random index with probability proportional to its weight, cum holds
+// cumulative weights.
+func pick(rng *rand.Rand, cum []float64) int {
+	r := rng.Float64() * cum[len(cum)-1]
+	return sort.Search(len(cum), func(i int) bool {
+		return cum[i] > r
+	})
+}
+
+// Zipf draws keys 0..n-1 by Zipf's law with exponent s > 1, so a few keys get most
+// of the items. Every key is placed into a fixed random point, items with the same key
+// have the same values.
+func Zipf(s float64, n uint64) Distribution {
+	return func(rng *rand.Rand, dims int) (Sampler, error) {
+		if s <= 1 {
+			return nil, errors.Errorf("zipf exponent(%v) must be greater than 1", s)
+		}
+		if n == 0 {
+			return nil, errors.New("number of keys must be positive")
+		}
+		z := rand.NewZipf(rng, s, 1, n-1)
+		seed := rng.Uint64()
+		return func(int) []float64 {
+			return keyPoint(seed, z.Uint64(), dims)
+		}, nil
+	}
+}
+
+// keyPoint returns pseudo-random point of the key.
+func keyPoint(seed, key uint64, dims int) []float64 {
+	p := make([]float64, dims)
+	x := seed ^ key*0x9e3779b97f4a7c15
+	for iter := range p {
+		x = splitmix64(x)
+		p[iter] = float64(x>>11) / (1 << 53)
+	}
+	return p
+}
+
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
@@ -0,0 +1,80 @@
+package workload
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/pkg/errors"
+)
+
+// city is a metropolitan area, population is in millions.
+type city struct {
+	lat, lon   float64
+	population float64
+}
+
+// cities are the largest metropolitan areas of the world.
+var cities = []city{
+	{35.68, 139.69, 37.4},  // Tokyo
+	{28.61, 77.21, 32.9},   // Delhi
+	{31.23, 121.47, 29.2},  // Shanghai
+	{23.81, 90.41, 23.2},   // Dhaka
+	{-23.55, -46.63, 22.6}, // Sao Paulo
+	{19.43, -99.13, 22.3},  // Mexico City
+	{30.04, 31.24, 22.2},   // Cairo
+	{39.90, 116.41, 21.8},  // Beijing
+	{19.08, 72.88, 21.3},   // Mumbai
+	{34.69, 135.50, 19.0},  // Osaka
+	{29.56, 106.55, 17.3},  // Chongqing
+	{24.86, 67.01, 17.2},   // Karachi
+	{41.01, 28.98, 15.8},   // Istanbul
+	{-34.60, -58.38, 15.5}, // Buenos Aires
+	{22.57, 88.36, 15.3},   // Kolkata
+	{6.52, 3.38, 15.9},     // Lagos
+	{-4.44, 15.27, 15.6},   // Kinshasa
+	{14.60, 120.98, 14.4},  // Manila
+	{-22.91, -43.17, 13.7}, // Rio de Janeiro
+	{23.13, 113.26, 14.3},  // Guangzhou
+	{40.71, -74.01, 18.9},  // New York
+	{34.05, -118.24, 12.5}, // Los Angeles
+	{55.76, 37.62, 12.6},   // Moscow
+	{48.86, 2.35, 11.1},    // Paris
+	{51.51, -0.13, 9.6},    // London
+	{-6.21, 106.85, 11.0},  // Jakarta
+	{13.76, 100.50, 10.9},  // Bangkok
+	{37.57, 126.98, 10.0},  // Seoul
+	{41.88, -87.63, 8.9},   // Chicago
+	{-33.87, 151.21, 5.3},  // Sydney
+}
+
+// geoBackground is the share of points spread uniformly over the globe.
+const geoBackground = 0.05
+
+// GeoClusters distributes points around the largest cities of the world proportionally
+// to their population, larger cities also spread wider. A small share of points is
+// spread uniformly. It requires 2 dimensions: latitude and longitude.
+func GeoClusters() Distribution {
+	return func(rng *rand.Rand, dims int) (Sampler, error) {
+		if dims != 2 {
+			return nil, errors.New("geo clusters require 2 dimensions")
+		}
+		cum := make([]float64, len(cities))
+		var total float64
+		for iter, c := range cities {
+			total += c.population
+			cum[iter] = total
+		}
+		return func(int) []float64 {
+			if rng.Float64() < geoBackground {
+				return []float64{rng.Float64(), rng.Float64()}
+			}
+			c := cities[pick(rng, cum)]
+			// Standard deviation in degrees.
+			sd := 0.2 * math.Sqrt(c.population)
+			return []float64{
+				(c.lat + rng.NormFloat64()*sd + 90) / 180,
+				(c.lon + rng.NormFloat64()*sd + 180) / 360,
+			}
+		}, nil
+	}
+}
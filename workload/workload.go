@@ -0,0 +1,135 @@
+// Package workload generates reproducible synthetic data for evaluation of optimizers.
+package workload
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/visheratin/balancer"
+)
+
+// Format defines how points of the distribution are turned into values of data items.
+type Format int
+
+const (
+	// Unit values are float64 coordinates of the point in [0, 1].
+	Unit Format = iota
+	// Geo values are float64 latitude and longitude for transform.SpaceTransform.
+	// The first coordinate of the point is mapped to [-90, 90], the second - to [-180, 180].
+	Geo
+	// Key value is a single string for transform.KVTransform. Equal points produce
+	// equal keys.
+	Key
+)
+
+// keyBits is the precision of coordinates encoded into keys.
+const keyBits = 32
+
+// Config describes generated data items.
+//
+// Seed - seed of the random number generator, workloads with the same seed and
+// distribution produce the same items.
+//
+// Dims - number of coordinates of generated points, 2 if not set. Geo format requires 2.
+//
+// MinSize, MaxSize - sizes of items are uniformly distributed in [MinSize, MaxSize].
+// If MaxSize is not set, all items have size MinSize, or 1 if it is not set either.
+type Config struct {
+	Seed    int64
+	Dims    int
+	MinSize uint64
+	MaxSize uint64
+	Format  Format
+}
+
+// Workload is a stream of data items.
+type Workload struct {
+	cfg    Config
+	rng    *rand.Rand
+	sample Sampler
+	step   int
+}
+
+func New(d Distribution, cfg Config) (*Workload, error) {
+	if cfg.Dims == 0 {
+		cfg.Dims = 2
+	}
+	if cfg.Dims < 0 {
+		return nil, errors.Errorf("number of dimensions(%d) must be positive", cfg.Dims)
+	}
+	if cfg.Format == Geo && cfg.Dims != 2 {
+		return nil, errors.New("geo format requires 2 dimensions")
+	}
+	if cfg.MinSize == 0 {
+		cfg.MinSize = 1
+	}
+	if cfg.MaxSize < cfg.MinSize {
+		cfg.MaxSize = cfg.MinSize
+	}
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	sample, err := d(rng, cfg.Dims)
+	if err != nil {
+		return nil, err
+	}
+	return &Workload{
+		cfg:    cfg,
+		rng:    rng,
+		sample: sample,
+	}, nil
+}
+
+// Next returns the next data item.
+func (w *Workload) Next() balancer.DataItem {
+	p := w.sample(w.step)
+	for iter := range p {
+		p[iter] = clamp(p[iter])
+	}
+	size := w.cfg.MinSize
+	if w.cfg.MaxSize > w.cfg.MinSize {
+		size += uint64(w.rng.Int63n(int64(w.cfg.MaxSize - w.cfg.MinSize + 1)))
+	}
+	id := fmt.Sprintf("item-%d", w.step)
+	w.step++
+	return balancer.NewDataItem(id, size, w.values(p)...)
+}
+
+// Items returns next n data items.
+func (w *Workload) Items(n int) []balancer.DataItem {
+	res := make([]balancer.DataItem, n)
+	for iter := range res {
+		res[iter] = w.Next()
+	}
+	return res
+}
+
+func (w *Workload) values(p []float64) []interface{} {
+	switch w.cfg.Format {
+	case Geo:
+		return []interface{}{p[0]*180 - 90, p[1]*360 - 180}
+	case Key:
+		parts := make([]string, len(p))
+		for iter := range p {
+			parts[iter] = fmt.Sprintf("%08x", uint64(p[iter]*(1<<keyBits-1)))
+		}
+		return []interface{}{strings.Join(parts, "")}
+	default:
+		res := make([]interface{}, len(p))
+		for iter := range p {
+			res[iter] = p[iter]
+		}
+		return res
+	}
+}
+
+func clamp(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
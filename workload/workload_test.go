@@ -0,0 +1,140 @@
+package workload
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/visheratin/balancer/curve"
+	"github.com/visheratin/balancer/transform"
+)
+
+func TestWorkloadReproducible(t *testing.T) {
+	dists := map[string]Distribution{
+		"uniform":  Uniform(),
+		"hotspots": RandomHotspots(3, 0.05),
+		"moving":   MovingHotspots(2, 0.05, 0.001),
+		"zipf":     Zipf(1.2, 1000),
+		"geo":      GeoClusters(),
+	}
+	for name, d := range dists {
+		t.Run(name, func(t *testing.T) {
+			cfg := Config{Seed: 42, MinSize: 10, MaxSize: 20}
+			w1, err := New(d, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w2, err := New(d, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			items1, items2 := w1.Items(100), w2.Items(100)
+			if !reflect.DeepEqual(items1, items2) {
+				t.Fatal("workloads with the same seed must produce the same items")
+			}
+			for _, d := range items1 {
+				if d.Size() < 10 || d.Size() > 20 {
+					t.Fatalf("size %d is out of range", d.Size())
+				}
+				for _, v := range d.Values() {
+					if f := v.(float64); f < 0 || f > 1 {
+						t.Fatalf("value %v is out of range", f)
+					}
+				}
+			}
+			w3, err := New(d, Config{Seed: 43})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reflect.DeepEqual(items1[0].Values(), w3.Next().Values()) {
+				t.Fatal("workloads with different seeds must differ")
+			}
+		})
+	}
+}
+
+func TestWorkloadFormats(t *testing.T) {
+	sfc, err := curve.NewCurve(curve.Hilbert, 2, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		format Format
+		tf     func([]interface{}, curve.Curve) ([]uint64, error)
+	}{
+		{Geo, transform.SpaceTransform},
+		{Key, transform.KVTransform},
+	}
+	for _, c := range cases {
+		w, err := New(GeoClusters(), Config{Seed: 1, Format: c.format})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range w.Items(1000) {
+			if _, err := c.tf(d.Values(), sfc); err != nil {
+				t.Fatalf("format %d: %v", c.format, err)
+			}
+		}
+	}
+	if _, err := New(Uniform(), Config{Dims: 3, Format: Geo}); err == nil {
+		t.Fatal("expected error for geo format with 3 dimensions")
+	}
+	if _, err := New(GeoClusters(), Config{Dims: 3}); err == nil {
+		t.Fatal("expected error for geo clusters with 3 dimensions")
+	}
+}
+
+func TestZipfKeys(t *testing.T) {
+	w, err := New(Zipf(1.5, 10000), Config{Seed: 7, Format: Key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	n := 10000
+	for _, d := range w.Items(n) {
+		counts[d.Values()[0].(string)]++
+	}
+	max := 0
+	for _, c := range counts {
+		if c > max {
+			max = c
+		}
+	}
+	if max < n/10 {
+		t.Fatalf("the hottest key got only %d of %d items", max, n)
+	}
+	if len(counts) < 100 {
+		t.Fatalf("expected long tail of keys, got %d distinct keys", len(counts))
+	}
+}
+
+func TestHotspots(t *testing.T) {
+	_, err := New(Hotspots(Hotspot{Center: []float64{0.5}, StdDev: 0.1, Weight: 1}), Config{})
+	if err == nil {
+		t.Fatal("expected error for center with wrong number of coordinates")
+	}
+	w, err := New(Hotspots(
+		Hotspot{Center: []float64{0.2, 0.2}, StdDev: 0.01, Weight: 3},
+		Hotspot{Center: []float64{0.8, 0.8}, StdDev: 0.01, Weight: 1, Drift: []float64{0, -0.0001}},
+	), Config{Seed: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var first, second int
+	var y []float64
+	for _, d := range w.Items(4000) {
+		v := d.Values()
+		if v[0].(float64) < 0.5 {
+			first++
+			continue
+		}
+		second++
+		y = append(y, v[1].(float64))
+	}
+	if first < 2*second {
+		t.Fatalf("expected 3:1 split between hotspots, got %d:%d", first, second)
+	}
+	// The second hotspot moves down by 0.4 over 4000 items.
+	if y[0] < 0.7 || y[len(y)-1] > 0.5 {
+		t.Fatalf("second hotspot did not move: from %v to %v", y[0], y[len(y)-1])
+	}
+}